@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// field is a struct field mapped to a column
+type field struct {
+	Column string
+	// Path is the Go selector of the field, e.g. Audit.CreatedBy
+	Path string
+	// Guard is the nil check of embedded pointers on the path, if any
+	Guard string
+	// Slice is set for slices other than []byte, bound as a list of values
+	Slice bool
+}
+
+// mappedStruct is a struct to generate code for
+type mappedStruct struct {
+	Name   string
+	Fields []field
+	// Allocs are embedded struct pointers to allocate
+	Allocs []alloc
+}
+
+// alloc is an embedded struct pointer field
+type alloc struct {
+	Path string
+	Type string
+}
+
+func (s mappedStruct) Columns() string {
+	return s.join(func(f field) string { return f.Column })
+}
+
+func (s mappedStruct) Values() string {
+	return s.join(func(f field) string { return ":" + f.Column })
+}
+
+func (s mappedStruct) Assignments() string {
+	return s.join(func(f field) string { return f.Column + " = :" + f.Column })
+}
+
+func (s mappedStruct) join(fn func(field) string) string {
+	parts := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		parts[i] = fn(f)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// columnName converts Go field name to snake case column name, the same way
+// dbmapper does
+func columnName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// generator resolves types declared in a package
+type generator struct {
+	structs map[string]*ast.StructType
+	types   map[string]ast.Expr
+	valuers map[string]bool
+}
+
+// isSlice reports whether expr is a slice type other than []byte, resolving
+// types declared in the package. Types of other packages are not resolved and
+// package types with a Value method are bound as a single value
+func (g *generator) isSlice(expr ast.Expr) bool {
+	for depth := 0; depth < 10; depth++ {
+		switch t := expr.(type) {
+		case *ast.ArrayType:
+			if t.Len != nil {
+				return false
+			}
+			elt, ok := t.Elt.(*ast.Ident)
+			return !ok || (elt.Name != "byte" && elt.Name != "uint8")
+		case *ast.Ident:
+			next, ok := g.types[t.Name]
+			if !ok || g.valuers[t.Name] {
+				return false
+			}
+			expr = next
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// valueReceiver returns the type name of fn's receiver when fn is a method
+// declared on a value receiver
+func valueReceiver(fn *ast.FuncDecl) (string, bool) {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return "", false
+	}
+	ident, ok := fn.Recv.List[0].Type.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	return ident.Name, true
+}
+
+func (g *generator) fields(s *ast.StructType, prefix, guard string, mapped *mappedStruct) error {
+	for _, f := range s.Fields.List {
+		tag := ""
+		tagged := false
+		if f.Tag != nil {
+			unquoted, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag, tagged = reflect.StructTag(unquoted).Lookup("db")
+		}
+		if tag == "-" {
+			continue
+		}
+		if len(f.Names) == 0 {
+			typ, isPtr := f.Type, false
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ, isPtr = star.X, true
+			}
+			ident, ok := typ.(*ast.Ident)
+			if !ok {
+				return fmt.Errorf("cannot resolve embedded field %s of %s", exprString(f.Type), mapped.Name)
+			}
+			if isPtr && !ast.IsExported(ident.Name) {
+				// Like dbmapper.Struct, it cannot be allocated by reflection
+				continue
+			}
+			embedded, found := g.structs[ident.Name]
+			if !tagged && found {
+				embeddedGuard := guard
+				if isPtr {
+					mapped.Allocs = append(mapped.Allocs, alloc{prefix + ident.Name, ident.Name})
+					if embeddedGuard != "" {
+						embeddedGuard += " && "
+					}
+					embeddedGuard += "v." + prefix + ident.Name + " != nil"
+				}
+				if err := g.fields(embedded, prefix+ident.Name+".", embeddedGuard, mapped); err != nil {
+					return err
+				}
+				continue
+			}
+			if !ast.IsExported(ident.Name) {
+				continue
+			}
+			f.Names = []*ast.Ident{ident}
+		}
+		for _, name := range f.Names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			column := tag
+			if column == "" {
+				column = columnName(name.Name)
+			}
+			mapped.Fields = append(mapped.Fields, field{column, prefix + name.Name, guard, g.isSlice(f.Type)})
+		}
+	}
+	return nil
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func hasDBTag(s *ast.StructType) bool {
+	for _, f := range s.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(f.Tag.Value); err == nil {
+			if _, ok := reflect.StructTag(unquoted).Lookup("db"); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// generate returns source of mappers for structs named in names, or every
+// struct with db tags declared in file when names is empty
+func generate(file string, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	target, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{make(map[string]*ast.StructType), make(map[string]ast.Expr), make(map[string]bool)}
+	dir := filepath.Dir(file)
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := pkgs[target.Name.Name]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found in %s", target.Name.Name, dir)
+	}
+	inFile := make([]string, 0)
+	for filename, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if recv, ok := valueReceiver(fn); ok && fn.Name.Name == "Value" {
+					g.valuers[recv] = true
+				}
+				continue
+			}
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.types[ts.Name.Name] = ts.Type
+				if s, ok := ts.Type.(*ast.StructType); ok {
+					g.structs[ts.Name.Name] = s
+					if filepath.Base(filename) == filepath.Base(file) && hasDBTag(s) {
+						inFile = append(inFile, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		sort.Strings(inFile)
+		names = inFile
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no struct with db tags found in %s", file)
+	}
+	mapped := make([]mappedStruct, 0, len(names))
+	for _, name := range names {
+		s, ok := g.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct %s not found in package %s", name, target.Name.Name)
+		}
+		m := mappedStruct{Name: name}
+		if err := g.fields(s, "", "", &m); err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, m)
+	}
+	var buf bytes.Buffer
+	err = codeTemplate.Execute(&buf, struct {
+		Package string
+		Structs []mappedStruct
+	}{target.Name.Name, mapped})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by dbmapper-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/ncrypthic/dbmapper"
+)
+{{range .Structs}}
+// {{.Name}}Columns is the column list of {{.Name}}
+const {{.Name}}Columns = "{{.Columns}}"
+
+// {{.Name}}Values is the named parameter list to insert {{.Name}}
+const {{.Name}}Values = "{{.Values}}"
+
+// {{.Name}}Assignments is the named parameter assignment list to update {{.Name}}
+const {{.Name}}Assignments = "{{.Assignments}}"
+
+// {{.Name}}Mapper returns a row mapper appending every row to dst
+func {{.Name}}Mapper(dst *[]{{.Name}}) dbmapper.RowMapper {
+	return func() *dbmapper.MappedColumns {
+		row := {{.Name}}{}
+		{{- range .Allocs}}
+		row.{{.Path}} = &{{.Type}}{}
+		{{- end}}
+		return dbmapper.Columns(
+			{{- range .Fields}}
+			dbmapper.Column("{{.Column}}").As(&row.{{.Path}}),
+			{{- end}}
+		).Then(func() error {
+			{{- if .Allocs}}
+			// Copy embedded structs, row is scanned again for the next row
+			// with ReuseRow
+			r := row
+			{{- range $i, $a := .Allocs}}
+			e{{$i}} := *row.{{$a.Path}}
+			r.{{$a.Path}} = &e{{$i}}
+			{{- end}}
+			*dst = append(*dst, r)
+			{{- else}}
+			*dst = append(*dst, row)
+			{{- end}}
+			return nil
+		})
+	}
+}
+
+// {{.Name}}Params returns parameters of every column of v, columns of nil
+// embedded structs are omitted and slices are bound as lists of values
+func {{.Name}}Params(v {{.Name}}) []dbmapper.Parameter {
+	params := make([]dbmapper.Parameter, 0, {{len .Fields}})
+	{{- range .Fields}}
+	{{- if .Guard}}
+	if {{.Guard}} {
+		{{- template "param" .}}
+	}
+	{{- else}}
+	{{- template "param" .}}
+	{{- end}}
+	{{- end}}
+	return params
+}
+{{end}}
+{{- define "param"}}
+	{{- if .Slice}}
+	{
+		values := make([]interface{}, len(v.{{.Path}}))
+		for i := range v.{{.Path}} {
+			values[i] = v.{{.Path}}[i]
+		}
+		params = append(params, dbmapper.Param("{{.Column}}", values...))
+	}
+	{{- else}}
+	params = append(params, dbmapper.Param("{{.Column}}", v.{{.Path}}))
+	{{- end}}
+{{- end}}`))
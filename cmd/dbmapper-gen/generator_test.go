@@ -0,0 +1,132 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const modelSource = `package models
+
+import (
+	"database/sql/driver"
+	"strings"
+	"time"
+)
+
+type Audit struct {
+	CreatedAt time.Time
+	UpdatedBy string
+}
+
+type base struct {
+	Hidden string
+}
+
+type Roles []string
+
+type Labels []string
+
+func (l Labels) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+type User struct {
+	*Audit
+	*base
+	ID       int64  ` + "`db:\"id\"`" + `
+	UserName string
+	Password string ` + "`db:\"-\"`" + `
+	Avatar   []byte
+	Tags     []string
+	Roles    Roles
+	Labels   Labels
+	internal string
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "models.go")
+	if err := os.WriteFile(file, []byte(modelSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(file, nil)
+	if err != nil {
+		t.Fatalf("Fail: expect no error got %v instead", err)
+	}
+	code := string(src)
+	for _, expected := range []string{
+		`const UserColumns = "created_at, updated_by, id, user_name, avatar, tags, roles, labels"`,
+		`const UserValues = ":created_at, :updated_by, :id, :user_name, :avatar, :tags, :roles, :labels"`,
+		`row.Audit = &Audit{}`,
+		"e0 := *row.Audit\n\t\t\tr.Audit = &e0\n\t\t\t*dst = append(*dst, r)",
+		`dbmapper.Column("updated_by").As(&row.Audit.UpdatedBy),`,
+		`params = append(params, dbmapper.Param("user_name", v.UserName))`,
+		"if v.Audit != nil {\n\t\tparams = append(params, dbmapper.Param(\"created_at\", v.Audit.CreatedAt))",
+		`params = append(params, dbmapper.Param("avatar", v.Avatar))`,
+		"values[i] = v.Tags[i]",
+		"values[i] = v.Roles[i]",
+		`params = append(params, dbmapper.Param("labels", v.Labels))`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Fail: expect generated code to contain %s got\n%s\ninstead", expected, code)
+		}
+	}
+	if strings.Contains(code, "hidden") {
+		t.Errorf("Fail: expect unexported embedded pointer to be skipped got\n%s\ninstead", code)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "models_dbmapper.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := typeCheck(dir); err != nil {
+		t.Errorf("Fail: expect generated code to type check got %v instead", err)
+	}
+	if strings.Contains(code, "AuditMapper") {
+		t.Errorf("Fail: expect untagged struct Audit to be skipped got\n%s\ninstead", code)
+	}
+	if _, err := generate(file, []string{"Missing"}); err == nil {
+		t.Errorf("Fail: expect error for unknown struct got nil instead")
+	}
+}
+
+// moduleImporter imports packages from source, resolving this module from
+// the test working directory
+type moduleImporter struct {
+	types.ImporterFrom
+	dir string
+}
+
+func (i moduleImporter) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, i.dir, 0)
+}
+
+// typeCheck type checks the package in dir
+func typeCheck(dir string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		files := make([]*ast.File, 0, len(pkg.Files))
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+		conf := types.Config{Importer: moduleImporter{importer.ForCompiler(fset, "source", nil).(types.ImporterFrom), wd}}
+		if _, err := conf.Check(pkg.Name, fset, files, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
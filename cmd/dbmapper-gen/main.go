@@ -0,0 +1,59 @@
+// Command dbmapper-gen generates dbmapper row mappers and parameter builders
+// from struct definitions, to be used with go:generate
+//
+//	//go:generate go run github.com/ncrypthic/dbmapper/cmd/dbmapper-gen -type User
+//
+// For every struct it generates:
+//
+//	const UserColumns = "id, name"            // SELECT column list
+//	const UserValues = ":id, :name"           // INSERT named parameters
+//	const UserAssignments = "id = :id, ..."   // UPDATE SET named parameters
+//	func UserMapper(dst *[]User) dbmapper.RowMapper
+//	func UserParams(v User) []dbmapper.Parameter
+//
+// Columns are named by the `db` tag or the snake cased field name, fields
+// tagged `db:"-"` are skipped and fields of embedded structs declared in the
+// same package are promoted. Slice fields other than []byte are bound as
+// lists of values, unless their type has a Value method or is declared in
+// another package
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	types := flag.String("type", "", "comma separated struct names, default all structs with db tags in the file")
+	output := flag.String("output", "", "output file name, default <file>_dbmapper.go")
+	flag.Parse()
+	file := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		file = flag.Arg(0)
+	}
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "usage: dbmapper-gen [-type T1,T2] [-output file] [file.go]")
+		os.Exit(2)
+	}
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+	src, err := generate(file, names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dbmapper-gen: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(file, ".go") + "_dbmapper.go"
+	} else if !filepath.IsAbs(*output) {
+		*output = filepath.Join(filepath.Dir(file), *output)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "dbmapper-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
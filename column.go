@@ -53,7 +53,7 @@ func Column(name string) ColumnMap {
 
 // Columns helper method to create slice of ColumnMap
 func Columns(columns ...ColumnMap) *MappedColumns {
-	return &MappedColumns{columns, func() error { return nil }}
+	return &MappedColumns{Columns: columns, cb: func() error { return nil }}
 }
 
 // ColumnMapper provides allowing post mapping callback to process
@@ -66,6 +66,8 @@ type ColumnMapper interface {
 type MappedColumns struct {
 	Columns []ColumnMap
 	cb      func() error
+	// reused is set when column targets are scanned again for every row
+	reused bool
 }
 
 // Then allows callback to proses result after row scan
@@ -0,0 +1,77 @@
+package dbmapper
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+// ParamConverter converts a parameter value bound for dialect d
+type ParamConverter func(d *Dialect, v interface{}) (interface{}, error)
+
+type converterKey struct {
+	dialect *Dialect
+	typ     reflect.Type
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = make(map[converterKey]ParamConverter)
+)
+
+// RegisterParamConverter registers fn converting parameter values of type t
+// for every dialect. A converter registered for an unnamed array type, e.g.
+// [16]byte, also converts named types of the same array type which do not
+// implement driver.Valuer
+func RegisterParamConverter(t reflect.Type, fn ParamConverter) {
+	registerConverter(converterKey{nil, t}, fn)
+}
+
+// RegisterParamConverter registers fn converting parameter values of type t
+// bound for dialect d. It takes precedence over converters registered for
+// every dialect
+func (d *Dialect) RegisterParamConverter(t reflect.Type, fn ParamConverter) {
+	registerConverter(converterKey{d, t}, fn)
+}
+
+func registerConverter(key converterKey, fn ParamConverter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[key] = fn
+}
+
+func lookupConverter(d *Dialect, t reflect.Type) (ParamConverter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	if fn, ok := converters[converterKey{d, t}]; ok && d != nil {
+		return fn, true
+	}
+	fn, ok := converters[converterKey{nil, t}]
+	return fn, ok
+}
+
+// convertValue returns v converted by converters registered for its type and
+// dialect d, by its driver.Valuer implementation, or by converters registered
+// for its unnamed array type, in that order
+func convertValue(d *Dialect, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	if fn, ok := lookupConverter(d, rv.Type()); ok {
+		return fn(d, v)
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		return valuer.Value()
+	}
+	if rv.Kind() == reflect.Array && rv.Type().Name() != "" {
+		unnamed := reflect.ArrayOf(rv.Len(), rv.Type().Elem())
+		if fn, ok := lookupConverter(d, unnamed); ok {
+			return fn(d, rv.Convert(unnamed).Interface())
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,264 @@
+package dbmapper
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PagingStyle is the syntax used to limit result rows
+type PagingStyle int
+
+const (
+	// LimitOffset appends `LIMIT ? OFFSET ?`
+	LimitOffset PagingStyle = iota
+	// OffsetFetch appends `OFFSET ? ROWS FETCH NEXT ? ROWS ONLY`
+	OffsetFetch
+	// LimitOnly appends `LIMIT ?`, offset is not supported
+	LimitOnly
+)
+
+// BytesStyle is the syntax of binary literals
+type BytesStyle int
+
+const (
+	// BytesX renders `X'cafe'`
+	BytesX BytesStyle = iota
+	// BytesHex renders `0xcafe`
+	BytesHex
+	// BytesEscape renders PostgreSQL `'\xcafe'::bytea`
+	BytesEscape
+	// BytesHexToRaw renders Oracle `HEXTORAW('cafe')`
+	BytesHexToRaw
+)
+
+// LikeStyle is how wildcards of LIKE pattern parameters are escaped
+type LikeStyle int
+
+const (
+	// LikeEscape escapes `%`, `_` and `\` with backslash, followed by an
+	// ESCAPE clause
+	LikeEscape LikeStyle = iota
+	// LikeEscapeBracket also escapes the `[` wildcard of SQL Server
+	LikeEscapeBracket
+	// LikeUnescaped leaves patterns unescaped, for databases without an
+	// escape character
+	LikeUnescaped
+)
+
+// Dialect describes how queries are rendered for a database
+type Dialect struct {
+	// Name of the dialect
+	Name string
+	// Placeholder is the bind parameter notation
+	Placeholder PlaceholderStyle
+	// MaxParams is the maximum placeholder count of a single statement,
+	// zero means unlimited
+	MaxParams int
+	// BackslashEscapes reports whether backslash is an escape character in
+	// string literals
+	BackslashEscapes bool
+	// HashComments reports whether `#` starts a line comment
+	HashComments bool
+	// IdentQuote is the character quoting identifiers, `"` when empty
+	IdentQuote string
+	// Paging is the syntax used to limit result rows
+	Paging PagingStyle
+	// NamedPrefix prefixes named parameters rendered with NamedArgs option,
+	// `@` when empty
+	NamedPrefix string
+	// NationalStrings prefixes string literals with N
+	NationalStrings bool
+	// Bytes is the syntax of binary literals
+	Bytes BytesStyle
+	// NumericBools renders booleans as 1 and 0 instead of TRUE and FALSE
+	NumericBools bool
+	// TimeLayout formats time literals, time.RFC3339Nano when empty
+	TimeLayout string
+	// TimePrefix precedes quoted time literals, e.g. `TIMESTAMP `
+	TimePrefix string
+	// Like is how wildcards of LIKE pattern parameters are escaped
+	Like LikeStyle
+}
+
+var (
+	// MySQL dialect
+	MySQL = &Dialect{Name: "mysql", Placeholder: QuestionMark, MaxParams: 65535, BackslashEscapes: true, HashComments: true, IdentQuote: "`",
+		TimeLayout: "2006-01-02 15:04:05.999999"}
+	// PostgreSQL dialect
+	PostgreSQL = &Dialect{Name: "postgres", Placeholder: DollarNumbered, MaxParams: 65535, Bytes: BytesEscape}
+	// SQLServer dialect
+	SQLServer = &Dialect{Name: "sqlserver", Placeholder: AtNumbered, MaxParams: 2100, Paging: OffsetFetch,
+		NationalStrings: true, Bytes: BytesHex, NumericBools: true, Like: LikeEscapeBracket}
+	// Oracle dialect
+	Oracle = &Dialect{Name: "oracle", Placeholder: ColonNumbered, MaxParams: 65535, Paging: OffsetFetch, NamedPrefix: ":",
+		Bytes: BytesHexToRaw, NumericBools: true, TimeLayout: "2006-01-02 15:04:05.999999999", TimePrefix: "TIMESTAMP "}
+	// SQLite dialect
+	SQLite = &Dialect{Name: "sqlite", Placeholder: QuestionMark, MaxParams: 32766}
+	// Cassandra dialect
+	Cassandra = &Dialect{Name: "cassandra", Placeholder: QuestionMark, MaxParams: 65535, Paging: LimitOnly, Bytes: BytesHex,
+		Like: LikeUnescaped}
+)
+
+// ansi is used when no dialect is specified
+var ansi = &Dialect{Name: "ansi"}
+
+// QuoteIdent returns identifier quoted for the dialect. Each part of a dot
+// qualified identifier is quoted separately. A nil dialect quotes like MySQL,
+// the syntax assumed by the lexer when no dialect is set
+func (d *Dialect) QuoteIdent(ident string) string {
+	if d == nil {
+		d = MySQL
+	}
+	quote := `"`
+	if d.IdentQuote != "" {
+		quote = d.IdentQuote
+	}
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// likePattern returns LIKE pattern of kind from s with escaped wildcards,
+// and the ESCAPE clause to follow the placeholder. The clause is written as
+// a string literal of the dialect, doubling the backslash when it escapes.
+// A nil dialect follows MySQL, the syntax assumed by the lexer when no
+// dialect is set
+func (d *Dialect) likePattern(kind likeKind, s string) (string, string) {
+	if d == nil {
+		d = MySQL
+	}
+	clause := ` ESCAPE '\'`
+	if d.BackslashEscapes {
+		clause = ` ESCAPE '\\'`
+	}
+	specials := `\%_`
+	switch d.Like {
+	case LikeEscapeBracket:
+		specials += "["
+	case LikeUnescaped:
+		clause, specials = "", ""
+	}
+	if specials != "" {
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune(specials, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		s = b.String()
+	}
+	switch kind {
+	case likeContains:
+		s = "%" + s + "%"
+	case likeStartsWith:
+		s = s + "%"
+	case likeEndsWith:
+		s = "%" + s
+	}
+	return s, clause
+}
+
+// Literal returns v rendered as SQL literal. It is meant for logging and
+// debugging, never to build executed statements
+func (d *Dialect) Literal(v interface{}) string {
+	if d == nil {
+		d = ansi
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "NULL"
+		}
+		v = value
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "NULL"
+		}
+		return d.Literal(rv.Elem().Interface())
+	}
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return d.quoteString(val)
+	case []byte:
+		return d.bytesLiteral(val)
+	case bool:
+		return d.boolLiteral(val)
+	case time.Time:
+		return d.timeLiteral(val)
+	}
+	// Numeric and bool kinds are bound as such, even with a String method
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+	case reflect.Bool:
+		return d.boolLiteral(rv.Bool())
+	}
+	if stringer, ok := v.(fmt.Stringer); ok {
+		return d.quoteString(stringer.String())
+	}
+	if rv.Kind() == reflect.String {
+		return d.quoteString(rv.String())
+	}
+	return d.quoteString(fmt.Sprint(v))
+}
+
+func (d *Dialect) quoteString(s string) string {
+	if d.BackslashEscapes {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	s = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	if d.NationalStrings {
+		s = "N" + s
+	}
+	return s
+}
+
+func (d *Dialect) bytesLiteral(b []byte) string {
+	switch d.Bytes {
+	case BytesEscape:
+		return `'\x` + hex.EncodeToString(b) + "'::bytea"
+	case BytesHex:
+		return "0x" + hex.EncodeToString(b)
+	case BytesHexToRaw:
+		return "HEXTORAW('" + hex.EncodeToString(b) + "')"
+	default:
+		return "X'" + hex.EncodeToString(b) + "'"
+	}
+}
+
+func (d *Dialect) boolLiteral(b bool) string {
+	switch {
+	case d.NumericBools && b:
+		return "1"
+	case d.NumericBools:
+		return "0"
+	case b:
+		return "TRUE"
+	default:
+		return "FALSE"
+	}
+}
+
+func (d *Dialect) timeLiteral(t time.Time) string {
+	layout := d.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return d.TimePrefix + "'" + t.Format(layout) + "'"
+}
@@ -0,0 +1,103 @@
+package dbmapper
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type status int
+
+const statusActive status = 1
+
+func (s status) String() string {
+	return "active"
+}
+
+func TestInterpolated(t *testing.T) {
+	sqlServer, oracle := *SQLServer, *Oracle
+	namedSql := "select id from test where name = :name and id IN (:ids) and password = :password and active = :active"
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		dialect     *Dialect
+		expectedSql string
+	}{
+		{MySQL, `select id from test where name = 'o''neil\\' and id IN (1, 2) and password = *** and active = TRUE`},
+		{PostgreSQL, `select id from test where name = 'o''neil\' and id IN (1, 2) and password = *** and active = TRUE`},
+		{SQLServer, `select id from test where name = N'o''neil\' and id IN (1, 2) and password = *** and active = 1`},
+	}
+	for _, c := range cases {
+		q := Prepare(namedSql, UseDialect(c.dialect)).With(
+			Param("name", `o'neil\`),
+			Param("ids", 1, 2),
+			Secret("password", "s3cr3t"),
+			Param("active", true),
+		)
+		if q.Interpolated(nil) != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.Interpolated(nil))
+		}
+		if len(q.Params()) != 5 || q.Params()[3] != "s3cr3t" {
+			t.Errorf("Fail: expect secret value to be bound, got %v instead", q.Params())
+		}
+	}
+	literals := []struct {
+		dialect  *Dialect
+		value    interface{}
+		expected string
+	}{
+		{nil, nil, "NULL"},
+		{nil, sql.NullString{}, "NULL"},
+		{nil, sql.NullString{String: "a", Valid: true}, "'a'"},
+		{nil, 1.5, "1.5"},
+		{MySQL, []byte{0xca, 0xfe}, "X'cafe'"},
+		{PostgreSQL, []byte{0xca, 0xfe}, `'\xcafe'::bytea`},
+		{Cassandra, []byte{0xca, 0xfe}, "0xcafe"},
+		{MySQL, created, "'2020-01-02 03:04:05'"},
+		{PostgreSQL, &created, "'2020-01-02T03:04:05Z'"},
+		{nil, time.Second, "1000000000"},
+		{nil, statusActive, "1"},
+		{&sqlServer, true, "1"},
+		{&sqlServer, "a", "N'a'"},
+		{&oracle, created, "TIMESTAMP '2020-01-02 03:04:05'"},
+	}
+	for _, l := range literals {
+		if lit := l.dialect.Literal(l.value); lit != l.expected {
+			t.Errorf("Fail: expect %v literal, got %v instead", l.expected, lit)
+		}
+	}
+}
+
+type money int64
+
+func TestParamConverter(t *testing.T) {
+	dialect := &Dialect{Name: "test"}
+	RegisterParamConverter(reflect.TypeOf(money(0)), func(_ *Dialect, v interface{}) (interface{}, error) {
+		return float64(v.(money)) / 100, nil
+	})
+	dialect.RegisterParamConverter(reflect.TypeOf(money(0)), func(_ *Dialect, v interface{}) (interface{}, error) {
+		if v.(money) < 0 {
+			return nil, errors.New("negative amount")
+		}
+		return int64(v.(money)), nil
+	})
+	q := Prepare("select id from test where amount IN (:amounts)").With(Param("amounts", money(150), money(200)))
+	if q.Params()[0] != 1.5 || q.Params()[1] != 2.0 {
+		t.Errorf("Fail: expect amounts converted by global converter, got %v instead", q.Params())
+	}
+	q = Prepare("select id from test where amount IN (:amounts)", UseDialect(dialect), NamedArgs()).With(Param("amounts", money(150), money(200)))
+	if q.Params()[0].(sql.NamedArg).Value != int64(150) || q.Params()[1].(sql.NamedArg).Value != int64(200) {
+		t.Errorf("Fail: expect amounts converted by dialect converter, got %v instead", q.Params())
+	}
+	q = Prepare("select id from test where amount = :amount", UseDialect(dialect)).With(Param("amount", money(-1)))
+	var valueErr *ParamValueError
+	if !errors.As(q.Error(), &valueErr) || valueErr.Name != "amount" {
+		t.Errorf("Fail: expect ParamValueError for amount, got %v instead", q.Error())
+	}
+	var nullable *sql.NullInt64
+	q = Prepare("select id from test where a = :a and b = :b").With(Param("a", sql.NullInt64{}), Param("b", nullable))
+	if q.Params()[0] != nil || q.Params()[1] != nil {
+		t.Errorf("Fail: expect driver.Valuer values converted to nil, got %v instead", q.Params())
+	}
+}
@@ -0,0 +1,15 @@
+package cassandra
+
+import (
+	"reflect"
+
+	"github.com/gocql/gocql"
+	. "github.com/ncrypthic/dbmapper"
+)
+
+func init() {
+	// Bind uuid like [16]byte values to uuid and timeuuid columns
+	Cassandra.RegisterParamConverter(reflect.TypeOf([16]byte{}), func(_ *Dialect, v interface{}) (interface{}, error) {
+		return gocql.UUID(v.([16]byte)), nil
+	})
+}
@@ -1,8 +1,7 @@
 package cassandra
 
 import (
-	"fmt"
-
+	"github.com/gocql/gocql"
 	. "github.com/ncrypthic/dbmapper"
 )
 
@@ -10,46 +9,94 @@ type mapper struct {
 	query CqlQuery
 }
 
-func (m *mapper) targets(mapTarget map[string]*interface{}, names []string) []interface{} {
-	result := make([]interface{}, len(names))
-	for i, name := range names {
-		if target, ok := mapTarget[name]; ok {
-			result[i] = *target
-		} else {
-			result[i] = nil
-		}
+// lazyRow calls RowMapper when the first column of a row is unmarshaled, so
+// it is only called for rows which exist
+type lazyRow struct {
+	rowMapper RowMapper
+	opts      *Options
+	plan      *ScanPlan
+	mapped    *MappedColumns
+	dest      []interface{}
+	err       error
+}
+
+func (r *lazyRow) load() error {
+	if r.mapped != nil || r.err != nil {
+		return r.err
+	}
+	r.mapped = r.rowMapper()
+	if r.dest, r.err = r.plan.Dest(r.mapped); r.err != nil {
+		r.opts.Log(LevelError, "invalid column mapping", Field{Key: "error", Value: r.err})
 	}
-	return result
+	return r.err
 }
 
-func (m *mapper) Map(rowMapper RowMapper) (mapErr error) {
-	rowMap := rowMapper()
-	var dbColumns []string
+// lazyColumn is scan destination of a result column, loading its row on
+// first use
+type lazyColumn struct {
+	row *lazyRow
+	pos int
+}
+
+func (c *lazyColumn) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if err := c.row.load(); err != nil {
+		return err
+	}
+	if dest := c.row.dest[c.pos]; dest != nil {
+		return gocql.Unmarshal(info, data, dest)
+	}
+	return nil
+}
+
+func (m *mapper) Map(rowMapper RowMapper, opts ...Option) (mapErr error) {
+	o := NewOptions(opts...)
 	rs := m.query.Iter()
+	dbColumns := make([]string, 0)
+	for _, cqlColumn := range rs.Columns() {
+		dbColumns = append(dbColumns, cqlColumn.Name)
+	}
+	// Resolve columns before iterating, so mismatches are reported for empty
+	// results too. The row mapped here is used for the first row
+	row := &lazyRow{rowMapper: rowMapper, opts: o, mapped: rowMapper()}
+	row.plan = NewScanPlan(dbColumns, row.mapped, nil)
+	if mapErr = row.plan.Check(o); mapErr != nil {
+		rs.Close()
+		return mapErr
+	}
+	if row.dest, mapErr = row.plan.Dest(row.mapped); mapErr != nil {
+		o.Log(LevelError, "invalid column mapping", Field{Key: "error", Value: mapErr})
+		rs.Close()
+		return mapErr
+	}
 	if rs.NumRows() == 0 {
 		return ErrNoRows
 	}
+	dest := make([]interface{}, len(dbColumns))
+	for i := range dest {
+		dest[i] = &lazyColumn{row, i}
+	}
 	for {
-		dbColumns = make([]string, 0)
-		for _, cqlColumn := range rs.Columns() {
-			dbColumns = append(dbColumns, cqlColumn.Name)
+		scanOk := rs.Scan(dest...)
+		if row.err != nil {
+			rs.Close()
+			return row.err
 		}
-		targets := rowMap.Columns
-		targetMap := make(map[string]*interface{})
-		for _, column := range targets {
-			if columnErr := column.Error(); columnErr != nil {
-				mapErr = columnErr
-				fmt.Printf("%+v\n", mapErr)
-			}
-			targetMap[column.Name()] = column.Target()
-		}
-		dest := m.targets(targetMap, dbColumns)
-		if scanOk := rs.Scan(dest...); !scanOk {
+		if !scanOk {
 			break
 		}
-		if mapErr = rowMap.Done(); mapErr != nil {
+		// A result without columns unmarshals nothing
+		if mapErr = row.load(); mapErr != nil {
+			rs.Close()
+			return mapErr
+		}
+		if mapErr = row.mapped.Done(); mapErr != nil {
 			return mapErr
 		}
+		if o.ReuseRow {
+			dest = row.dest
+		} else {
+			row.mapped = nil
+		}
 	}
 	return rs.Close()
 }
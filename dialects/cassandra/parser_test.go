@@ -62,6 +62,19 @@ func (i *mockCqlIter) Close() error {
 
 func (i *mockCqlIter) assignVal(dest interface{}, source interface{}) {
 	switch d := dest.(type) {
+	case gocql.Unmarshaler:
+		// Like gocql, marshal the value to unmarshal it into the destination
+		typ := gocql.TypeVarchar
+		switch source.(type) {
+		case bool:
+			typ = gocql.TypeBoolean
+		case uint:
+			typ = gocql.TypeBigInt
+		}
+		info := gocql.NewNativeType(4, typ, "")
+		if data, err := gocql.Marshal(info, source); err == nil {
+			d.UnmarshalCQL(info, data)
+		}
 	case **string:
 		switch s := source.(type) {
 		case *string:
@@ -193,3 +206,69 @@ func TestQueryMapper(t *testing.T) {
 		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
 	}
 }
+
+type uuid [16]byte
+
+func TestParamConverter(t *testing.T) {
+	id := uuid{0xca, 0xfe}
+	q := Prepare("select id from users where id = :id", UseDialect(Cassandra)).With(Param("id", id))
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	if u, ok := q.Params()[0].(gocql.UUID); !ok || u[0] != 0xca {
+		t.Errorf("Fail: expect uuid bound as gocql.UUID, got %#v instead", q.Params()[0])
+	}
+}
+
+type Tagged struct {
+	ID   string
+	Name *string
+}
+
+func taggedMapper(result *[]Tagged, calls *int) RowMapper {
+	return func() *MappedColumns {
+		*calls++
+		row := Tagged{Name: new(string)}
+		return Columns(
+			Column("name").As(row.Name),
+		).Then(func() error {
+			*result = append(*result, row)
+			return nil
+		})
+	}
+}
+
+func TestRowTargets(t *testing.T) {
+	defer resetIter()
+	rows, calls := make([]Tagged, 0), 0
+	if err := ParseCqlQuery(Query("SELECT name FROM users")).Map(taggedMapper(&rows, &calls)); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if calls != 3 || len(rows) != 3 || *rows[0].Name != "alice" || *rows[1].Name != "bob" || *rows[2].Name != "charlie" {
+		t.Errorf("Fail: expect a fresh row per result row, got %d calls and %v instead", calls, rows)
+	}
+	resetIter()
+	rows, calls = make([]Tagged, 0), 0
+	if err := ParseCqlQuery(Query("SELECT name FROM users")).Map(taggedMapper(&rows, &calls), ReuseRow()); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if calls != 1 || len(rows) != 3 || rows[0].Name != rows[2].Name {
+		t.Errorf("Fail: expect a single reused row, got %d calls and %v instead", calls, rows)
+	}
+}
+
+func TestStrictColumns(t *testing.T) {
+	defer resetIter()
+	rows, calls := make([]Tagged, 0), 0
+	err := ParseCqlQuery(Query("SELECT name FROM users")).Map(taggedMapper(&rows, &calls), StrictColumns())
+	mismatch, ok := err.(*ColumnMismatchError)
+	if !ok {
+		t.Fatalf("Fail: expect *ColumnMismatchError, got %v instead", err)
+	}
+	if len(mismatch.Missing) != 0 || len(mismatch.Unmapped) != 3 {
+		t.Errorf("Fail: expect unmapped [id active opt_field], got %v instead", mismatch)
+	}
+	if calls != 1 || len(rows) != 0 {
+		t.Errorf("Fail: expect no row mapped, got %d calls and %v instead", calls, rows)
+	}
+}
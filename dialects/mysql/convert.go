@@ -0,0 +1,15 @@
+package mysql
+
+import (
+	"reflect"
+
+	. "github.com/ncrypthic/dbmapper"
+)
+
+func init() {
+	// Bind uuid like [16]byte values to BINARY(16) columns
+	MySQL.RegisterParamConverter(reflect.TypeOf([16]byte{}), func(_ *Dialect, v interface{}) (interface{}, error) {
+		b := v.([16]byte)
+		return b[:], nil
+	})
+}
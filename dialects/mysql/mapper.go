@@ -13,55 +13,48 @@ func (d *dummy) Scan(_ interface{}) error {
 	return nil
 }
 
+// discard is shared by every unmapped column
+var discard = new(dummy)
+
 type mapper struct {
 	rows *sql.Rows
 	err  error
 }
 
-func (m *mapper) targets(mapTarget map[string]*interface{}, names []string) []interface{} {
-	result := make([]interface{}, len(names))
-	for i, name := range names {
-		if target, ok := mapTarget[name]; ok {
-			result[i] = *target
-		} else {
-			result[i] = new(dummy)
-		}
-	}
-	return result
-}
-
-func (m *mapper) Map(rowMapper RowMapper) (mapErr error) {
+func (m *mapper) Map(rowMapper RowMapper, opts ...Option) (mapErr error) {
 	if m.err != nil {
 		return m.err
 	}
-	var dbColumns []string
-	rowMap := rowMapper()
+	o := NewOptions(opts...)
 	defer m.rows.Close()
+	// Resolve columns before iterating, so mismatches are reported for empty
+	// results too. The row mapped here is used for the first row
+	dbColumns, err := m.rows.Columns()
+	if err != nil {
+		return err
+	}
+	rowMap := rowMapper()
+	plan := NewScanPlan(dbColumns, rowMap, discard)
+	if mapErr = plan.Check(o); mapErr != nil {
+		return
+	}
+	dest, mapErr := plan.Dest(rowMap)
+	if mapErr != nil {
+		o.Log(LevelError, "invalid column mapping", Field{Key: "error", Value: mapErr})
+		return
+	}
 	isEmpty := true
 	for m.rows.Next() {
 		if isEmpty {
 			isEmpty = false
 		}
-		targets := rowMap.Columns
-		targetMap := make(map[string]*interface{})
-	TargetLoop:
-		for _, column := range targets {
-			if columnErr := column.Error(); columnErr != nil {
-				mapErr = columnErr
-				break TargetLoop
+		if rowMap == nil {
+			rowMap = rowMapper()
+			if dest, mapErr = plan.Dest(rowMap); mapErr != nil {
+				o.Log(LevelError, "invalid column mapping", Field{Key: "error", Value: mapErr})
+				return
 			}
-			targetMap[column.Name()] = column.Target()
-		}
-		if mapErr != nil {
-			return mapErr
-		}
-		if dbColumns == nil {
-			dbColumns, mapErr = m.rows.Columns()
 		}
-		if mapErr != nil {
-			return
-		}
-		dest := m.targets(targetMap, dbColumns)
 		mapErr = m.rows.Scan(dest...)
 		if mapErr != nil {
 			return
@@ -70,6 +63,9 @@ func (m *mapper) Map(rowMapper RowMapper) (mapErr error) {
 		if mapErr != nil {
 			return
 		}
+		if !o.ReuseRow {
+			rowMap = nil
+		}
 	}
 	if isEmpty {
 		return sql.ErrNoRows
@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	sqlMock "github.com/DATA-DOG/go-sqlmock"
+	. "github.com/ncrypthic/dbmapper"
+)
+
+func benchmarkMap(b *testing.B, rowMapper func(*[]User) RowMapper, opts ...Option) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	columns := []string{"id", "name", "active", "opt_string", "extra_1", "extra_2"}
+	row := []driver.Value{"1", "alice", true, "11111111", 1, 2}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		rows := sqlMock.NewRows(columns)
+		for j := 0; j < 1000; j++ {
+			rows.AddRow(row...)
+		}
+		mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(rows)
+		r, _ := db.Query("SELECT * FROM users")
+		users := make([]User, 0, 1000)
+		b.StartTimer()
+		if err := Parse(r, nil).Map(rowMapper(&users), opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func structMapper(users *[]User) RowMapper {
+	return StructOf(users)
+}
+
+func BenchmarkMap(b *testing.B) {
+	benchmarkMap(b, usersSqlMapper)
+}
+
+func BenchmarkMapReuseRow(b *testing.B) {
+	benchmarkMap(b, usersSqlMapper, ReuseRow())
+}
+
+func BenchmarkMapStruct(b *testing.B) {
+	benchmarkMap(b, structMapper)
+}
+
+func BenchmarkMapStructReuseRow(b *testing.B) {
+	benchmarkMap(b, structMapper, ReuseRow())
+}
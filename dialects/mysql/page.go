@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"database/sql"
+
+	. "github.com/ncrypthic/dbmapper"
+)
+
+// Queryer executes queries, e.g. *sql.DB or *sql.Tx
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// QueryPage maps rows of paged query q with rowMapper and returns the total
+// row count of q without paging, counted with dbmapper.Count using the same
+// parameters. An empty page is not an error
+func QueryPage(db Queryer, q QueryMapper, rowMapper RowMapper, opts ...Option) (int64, error) {
+	if q.Error() != nil {
+		return 0, q.Error()
+	}
+	if q.Skip() {
+		return 0, nil
+	}
+	count := Count(q)
+	if count.Error() != nil {
+		return 0, count.Error()
+	}
+	totals := make([]int64, 0, 1)
+	if err := Parse(db.Query(count.SQL(), count.Params()...)).Map(Int64("total", &totals), opts...); err != nil {
+		return 0, err
+	}
+	if err := Parse(db.Query(q.SQL(), q.Params()...)).Map(rowMapper, opts...); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return totals[0], nil
+}
@@ -0,0 +1,38 @@
+package mysql
+
+import (
+	"regexp"
+	"testing"
+
+	sqlMock "github.com/DATA-DOG/go-sqlmock"
+	. "github.com/ncrypthic/dbmapper"
+)
+
+func TestQueryPage(t *testing.T) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	listUsers := Prepare("SELECT id, name, active, opt_string FROM users WHERE active = :active ORDER BY id", UseDialect(MySQL))
+	q := Page(listUsers.With(Param("active", true)), 2, 2)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS total FROM (SELECT id, name, active, opt_string FROM users WHERE active = ?) t")).
+		WithArgs(true).
+		WillReturnRows(sqlMock.NewRows([]string{"total"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, active, opt_string FROM users WHERE active = ? ORDER BY id LIMIT ? OFFSET ?")).
+		WithArgs(true, 2, 2).
+		WillReturnRows(sqlMock.NewRows([]string{"id", "name", "active", "opt_string"}).
+			AddRow(3, "charlie", true, nil).
+			AddRow(4, "dave", true, nil))
+	users := make([]User, 0)
+	total, err := QueryPage(db, q, usersSqlMapper(&users))
+	if err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if total != 5 || len(users) != 2 {
+		t.Errorf("Fail: expect 2 of 5 users, got %v of %v instead", len(users), total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Fail: %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package mysql
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -154,3 +156,106 @@ func TestQueryMapper(t *testing.T) {
 		}
 	}
 }
+
+type uuid [16]byte
+
+type textUUID [16]byte
+
+func (u textUUID) Value() (driver.Value, error) {
+	return fmt.Sprintf("%x", u[:]), nil
+}
+
+func TestParamConverter(t *testing.T) {
+	id := uuid{0xca, 0xfe}
+	q := Prepare("select id from users where id = :id and name = :name", UseDialect(MySQL)).With(
+		Param("id", id),
+		Param("name", sql.NullString{String: "alice", Valid: true}),
+	)
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	if b, ok := q.Params()[0].([]byte); !ok || len(b) != 16 || b[0] != 0xca {
+		t.Errorf("Fail: expect uuid bound as BINARY(16), got %#v instead", q.Params()[0])
+	}
+	if q.Params()[1] != "alice" {
+		t.Errorf("Fail: expect driver.Valuer to be converted, got %#v instead", q.Params()[1])
+	}
+	q = Prepare("select id from users where id = :id", UseDialect(MySQL)).With(Param("id", textUUID{0xca, 0xfe}))
+	if q.Params()[0] != "cafe0000000000000000000000000000" {
+		t.Errorf("Fail: expect driver.Valuer to take precedence over [16]byte converter, got %#v instead", q.Params()[0])
+	}
+	q = Prepare("select id from users where id = :id").With(Param("id", id))
+	if _, ok := q.Params()[0].(uuid); !ok {
+		t.Errorf("Fail: expect uuid not converted without dialect, got %#v instead", q.Params()[0])
+	}
+}
+
+type Tagged struct {
+	ID   string
+	Name *string
+}
+
+func taggedMapper(result *[]Tagged, calls *int) RowMapper {
+	return func() *MappedColumns {
+		*calls++
+		row := Tagged{Name: new(string)}
+		return Columns(
+			Column("id").As(&row.ID),
+			Column("name").As(row.Name),
+		).Then(func() error {
+			*result = append(*result, row)
+			return nil
+		})
+	}
+}
+
+func TestRowTargets(t *testing.T) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	columns := []string{"id", "name"}
+	mock.ExpectQuery("SELECT (.+) FROM tags").WillReturnRows(sqlMock.NewRows(columns).AddRow("1", "alice").AddRow("2", "bob"))
+	rows, calls := make([]Tagged, 0), 0
+	if err = Parse(db.Query("SELECT id, name FROM tags")).Map(taggedMapper(&rows, &calls)); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if calls != 2 || len(rows) != 2 || *rows[0].Name != "alice" || *rows[1].Name != "bob" {
+		t.Errorf("Fail: expect a fresh row per result row, got %d calls and %v instead", calls, rows)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM tags").WillReturnRows(sqlMock.NewRows(columns).AddRow("1", "alice").AddRow("2", "bob"))
+	rows, calls = make([]Tagged, 0), 0
+	if err = Parse(db.Query("SELECT id, name FROM tags")).Map(taggedMapper(&rows, &calls), ReuseRow()); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if calls != 1 || len(rows) != 2 || rows[0].ID != "1" || rows[0].Name != rows[1].Name {
+		t.Errorf("Fail: expect a single reused row, got %d calls and %v instead", calls, rows)
+	}
+}
+
+func TestStrictColumns(t *testing.T) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows([]string{"id", "name", "active", "country"}).AddRow("1", "alice", true, "ID"))
+	users := make([]User, 0)
+	err = Parse(db.Query("SELECT id, name, active, country FROM users")).Map(usersSqlMapper(&users), StrictColumns())
+	var mismatch *ColumnMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Fail: expect *ColumnMismatchError, got %v instead", err)
+	}
+	if len(mismatch.Missing) != 1 || mismatch.Missing[0] != "opt_string" || len(mismatch.Unmapped) != 1 || mismatch.Unmapped[0] != "country" {
+		t.Errorf("Fail: expect missing [opt_string] and unmapped [country], got %v instead", mismatch)
+	}
+	if len(users) != 0 {
+		t.Errorf("Fail: expect no row mapped, got %v instead", users)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows([]string{"id", "name", "active", "country"}))
+	err = Parse(db.Query("SELECT id, name, active, country FROM users")).Map(usersSqlMapper(&users), StrictColumns())
+	if !errors.As(err, &mismatch) {
+		t.Errorf("Fail: expect *ColumnMismatchError for empty result, got %v instead", err)
+	}
+}
@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"testing"
+
+	sqlMock "github.com/DATA-DOG/go-sqlmock"
+	. "github.com/ncrypthic/dbmapper"
+)
+
+type Audit struct {
+	CreatedBy string
+}
+
+type TaggedUser struct {
+	*Audit
+	ID        int64  `db:"id"`
+	Name      string `db:"user_name"`
+	Active    bool
+	OptString *string `db:"opt_string"`
+	Password  string  `db:"-"`
+}
+
+func TestStructMapper(t *testing.T) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	columns := []string{"id", "user_name", "active", "opt_string", "created_by", "password"}
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows(columns).
+		AddRow(1, "alice", true, nil, "admin", "secret").
+		AddRow(2, "bob", false, "11111111", "system", "secret"))
+	users := make([]TaggedUser, 0)
+	err = Parse(db.Query("SELECT * FROM users")).Map(StructOf(&users))
+	if err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Fail: expect 2 users, got %v instead", users)
+	}
+	if users[0].ID != 1 || users[0].Name != "alice" || !users[0].Active || users[0].OptString != nil || users[0].CreatedBy != "admin" {
+		t.Errorf("Fail: expect alice, got %+v instead", users[0])
+	}
+	if users[1].OptString == nil || *users[1].OptString != "11111111" || users[1].CreatedBy != "system" || users[1].Password != "" {
+		t.Errorf("Fail: expect bob, got %+v instead", users[1])
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows(columns).
+		AddRow(1, "alice", true, nil, "admin", "secret").
+		AddRow(2, "bob", false, "11111111", "system", "secret"))
+	pointers := make([]*TaggedUser, 0)
+	if err = Parse(db.Query("SELECT * FROM users")).Map(Struct(&pointers)); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if len(pointers) != 2 || pointers[0] == pointers[1] || pointers[0].Name != "alice" || pointers[1].Name != "bob" {
+		t.Errorf("Fail: expect distinct alice and bob, got %+v instead", pointers)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows(columns).
+		AddRow(1, "alice", true, nil, "admin", "secret").
+		AddRow(2, "bob", false, "11111111", "system", "secret"))
+	pointers = make([]*TaggedUser, 0)
+	if err = Parse(db.Query("SELECT * FROM users")).Map(Struct(&pointers), ReuseRow()); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if len(pointers) != 2 || pointers[0] == pointers[1] || pointers[0].Audit == pointers[1].Audit ||
+		pointers[0].Name != "alice" || pointers[0].CreatedBy != "admin" || pointers[1].CreatedBy != "system" {
+		t.Errorf("Fail: expect rows copied with ReuseRow, got %+v %+v instead", pointers[0], pointers[1])
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows(columns).AddRow(1, "alice", true, nil, "admin", "secret"))
+	if err = Parse(db.Query("SELECT * FROM users")).Map(Struct(users)); err == nil {
+		t.Errorf("Fail: expect error mapping into non pointer")
+	}
+}
+
+type base struct {
+	CreatedBy string
+}
+
+type Unexported struct {
+	*base
+	ID   int64 `db:"id"`
+	Name string
+}
+
+func TestStructUnexportedEmbedded(t *testing.T) {
+	db, mock, err := sqlMock.New()
+	if err != nil {
+		panic("failed to mock database")
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlMock.NewRows([]string{"id", "name", "created_by"}).AddRow(1, "alice", "admin"))
+	users := make([]Unexported, 0)
+	if err = Parse(db.Query("SELECT * FROM users")).Map(StructOf(&users)); err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	if len(users) != 1 || users[0].Name != "alice" || users[0].base != nil {
+		t.Errorf("Fail: expect alice without unexported embedded struct, got %+v instead", users)
+	}
+}
@@ -0,0 +1,106 @@
+package dbmapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MissingParamError is returned when a named parameter in the query is not
+// passed to QueryMapper.With
+type MissingParamError struct {
+	Name string
+	// Position is byte offset of the first reference in named query
+	Position int
+}
+
+func (e *MissingParamError) Error() string {
+	return fmt.Sprintf("missing parameter :%s at offset %d", e.Name, e.Position)
+}
+
+// EmptyParamError is returned when a parameter with EmptyError policy is
+// passed without any value
+type EmptyParamError struct {
+	Name string
+}
+
+func (e *EmptyParamError) Error() string {
+	return "parameter :" + e.Name + " has no values"
+}
+
+// UnusedParamError is returned in strict mode when a parameter passed to
+// QueryMapper.With is never referenced by the query
+type UnusedParamError struct {
+	Name string
+}
+
+func (e *UnusedParamError) Error() string {
+	return "parameter :" + e.Name + " is not used by the query"
+}
+
+// ParamValueError is returned when a parameter value cannot be bound
+type ParamValueError struct {
+	Name string
+	Err  error
+}
+
+func (e *ParamValueError) Error() string {
+	return "invalid value for parameter :" + e.Name + ": " + e.Err.Error()
+}
+
+func (e *ParamValueError) Unwrap() error {
+	return e.Err
+}
+
+// ColumnMismatchError is returned by strict result mapping when mapped
+// columns are missing from the result, or result columns are not mapped
+type ColumnMismatchError struct {
+	// Missing are mapped columns absent from the result
+	Missing []string
+	// Unmapped are result columns without a mapped column
+	Unmapped []string
+}
+
+func (e *ColumnMismatchError) Error() string {
+	return fmt.Sprintf("column mismatch: missing columns [%s], unmapped columns [%s]",
+		strings.Join(e.Missing, ", "), strings.Join(e.Unmapped, ", "))
+}
+
+// ParamErrors is a list of errors found while binding parameters
+type ParamErrors []error
+
+func (e ParamErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any error in the list matches target
+func (e ParamErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in the list that matches target
+func (e ParamErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns the single error, or the list when it has more than one
+func (e ParamErrors) err() error {
+	if len(e) == 1 {
+		return e[0]
+	}
+	return e
+}
@@ -0,0 +1,36 @@
+package dbmapper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParamErrors(t *testing.T) {
+	namedSql := "insert into test(id, name) values (:id, :name)"
+	q := Prepare(namedSql).With(Param("id", 1))
+	var missingErr *MissingParamError
+	if !errors.As(q.Error(), &missingErr) {
+		t.Fatalf("Fail: expect MissingParamError, got %v instead", q.Error())
+	}
+	if missingErr.Name != "name" || missingErr.Position != 40 {
+		t.Errorf("Fail: expect missing :name at offset 40, got %+v instead", missingErr)
+	}
+	q = Prepare(namedSql).With(Param("id", 1), Param("name", "alice"), Param("phone", "0827126"))
+	if q.Error() != nil {
+		t.Errorf("Fail: expect unused parameter to be ignored, got %v instead", q.Error())
+	}
+	q = Prepare(namedSql, StrictParams()).With(Param("id", 1), Param("name", "alice"), Param("phone", "0827126"))
+	var unusedErr *UnusedParamError
+	if !errors.As(q.Error(), &unusedErr) || unusedErr.Name != "phone" {
+		t.Errorf("Fail: expect UnusedParamError for phone, got %v instead", q.Error())
+	}
+	q = Prepare(namedSql, StrictParams()).With(Param("id", 1), Param("phone", "0827126"))
+	if !errors.As(q.Error(), &missingErr) || !errors.As(q.Error(), &unusedErr) {
+		t.Errorf("Fail: expect both missing and unused parameter errors, got %v instead", q.Error())
+	}
+	q = Prepare("insert into test(id, name) values :rows").With(Rows("rows", "abc"))
+	var valueErr *ParamValueError
+	if !errors.As(q.Error(), &valueErr) || valueErr.Name != "rows" {
+		t.Errorf("Fail: expect ParamValueError for rows, got %v instead", q.Error())
+	}
+}
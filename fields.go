@@ -0,0 +1,85 @@
+package dbmapper
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// structField is a struct field mapped to a column or parameter name
+type structField struct {
+	name  string
+	index []int
+}
+
+// columnName converts Go field name to snake case column name
+func columnName(field string) string {
+	var b strings.Builder
+	runes := []rune(field)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// structFields returns mapped fields of struct type t. Fields are named by
+// their `db` tag, or the snake cased field name when untagged. Fields tagged
+// `db:"-"` and unexported fields are skipped, fields of embedded structs are
+// promoted. Like encoding/json, pointers to unexported embedded structs are
+// skipped as they cannot be allocated
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			if f.Anonymous && f.PkgPath != "" {
+				continue
+			}
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+			for _, embedded := range structFields(ft) {
+				index := append([]int{i}, embedded.index...)
+				fields = append(fields, structField{embedded.name, index})
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = columnName(f.Name)
+		}
+		fields = append(fields, structField{name, []int{i}})
+	}
+	return fields
+}
+
+// fieldByIndex returns field of v at index, or false when it is inside a nil
+// embedded struct pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(idx)
+	}
+	return v, true
+}
@@ -0,0 +1,145 @@
+package dbmapper
+
+import (
+	"fmt"
+)
+
+// Fragment returns a piece of SQL bound with its own parameters. Fragments
+// can be joined with And and Or, and embedded into another query with Embed.
+// An embedded fragment is lexed again with the options of the query
+// embedding it, so its string literals follow the query dialect
+func Fragment(namedSql string, params ...Parameter) QueryMapper {
+	f := Prepare(namedSql).With(params...).(*query)
+	f.rebind = func(o *Options) QueryMapper {
+		return Prepare(namedSql, withOptions(o)).With(params...)
+	}
+	return f
+}
+
+// And joins fragments with AND. Joined fragments and the whole conjunction
+// are wrapped in parentheses, so it can be embedded next to other operators.
+// It renders the always true `1 = 1` when there is no fragment
+func And(fragments ...QueryMapper) QueryMapper {
+	return join(" AND ", "1 = 1", fragments)
+}
+
+// Or joins fragments with OR. Joined fragments and the whole disjunction are
+// wrapped in parentheses, so it can be embedded next to other operators. It
+// renders the always false `1 = 0` when there is no fragment
+func Or(fragments ...QueryMapper) QueryMapper {
+	return join(" OR ", "1 = 0", fragments)
+}
+
+// fragmentPieces returns bound pieces of fragment q
+func fragmentPieces(q QueryMapper) (*query, error) {
+	f, ok := q.(*query)
+	if !ok {
+		return nil, fmt.Errorf("cannot use %T as fragment", q)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.pieces == nil {
+		// Prepared but never bound, e.g. a fragment without parameters
+		f = f.bindAll(nil)
+	}
+	return f, f.err
+}
+
+// fragmentFor returns bound pieces of fragment q embedded into a query with
+// options o
+func fragmentFor(q QueryMapper, o *Options) (*query, error) {
+	if f, ok := q.(*query); ok && f.rebind != nil {
+		q = f.rebind(o)
+	}
+	return fragmentPieces(q)
+}
+
+func join(op, empty string, fragments []QueryMapper) QueryMapper {
+	joined := &query{opts: NewOptions(), paramNames: make([]string, 0)}
+	joined.rebind = func(o *Options) QueryMapper {
+		rebound := make([]QueryMapper, len(fragments))
+		for idx, q := range fragments {
+			if f, ok := q.(*query); ok && f.rebind != nil {
+				q = f.rebind(o)
+			}
+			rebound[idx] = q
+		}
+		return join(op, empty, rebound)
+	}
+	switch len(fragments) {
+	case 0:
+		joined.pieces = []piece{{text: empty}}
+	case 1:
+		f, err := fragmentPieces(fragments[0])
+		if err != nil {
+			joined.err = err
+			return joined
+		}
+		joined.grouped = true
+		if !f.grouped {
+			joined.pieces = append(joined.pieces, piece{text: "("})
+		}
+		for _, at := range f.refAt {
+			joined.refAt = append(joined.refAt, len(joined.pieces)+at)
+		}
+		joined.pieces = append(joined.pieces, f.pieces...)
+		if !f.grouped {
+			joined.pieces = append(joined.pieces, piece{text: ")"})
+		}
+		joined.paramNames = append(joined.paramNames, f.paramNames...)
+		joined.skip = f.skip
+	default:
+		joined.grouped = true
+		joined.pieces = append(joined.pieces, piece{text: "("})
+		for idx, q := range fragments {
+			f, err := fragmentPieces(q)
+			if err != nil {
+				joined.err = err
+				return joined
+			}
+			if idx > 0 {
+				joined.pieces = append(joined.pieces, piece{text: op})
+			}
+			if !f.grouped {
+				joined.pieces = append(joined.pieces, piece{text: "("})
+			}
+			for _, at := range f.refAt {
+				joined.refAt = append(joined.refAt, len(joined.pieces)+at)
+			}
+			joined.pieces = append(joined.pieces, f.pieces...)
+			if !f.grouped {
+				joined.pieces = append(joined.pieces, piece{text: ")"})
+			}
+			joined.paramNames = append(joined.paramNames, f.paramNames...)
+			joined.skip = joined.skip || f.skip
+		}
+		joined.pieces = append(joined.pieces, piece{text: ")"})
+	}
+	joined.render()
+	joined.namedSql = joined.sql
+	return joined
+}
+
+// embedParameter renders a fragment in place of a named parameter
+type embedParameter struct {
+	name string
+	q    QueryMapper
+}
+
+func (p *embedParameter) Name() string {
+	return p.name
+}
+
+func (p *embedParameter) Value() ([]interface{}, error) {
+	if p.q.Error() != nil {
+		return nil, p.q.Error()
+	}
+	return p.q.Params(), nil
+}
+
+// Embed returns parameter rendering fragment q, e.g. a WHERE clause built
+// with And and Or, or a subquery. Parameters of q are bound in place
+func Embed(name string, q QueryMapper) Parameter {
+	return &embedParameter{name, q}
+}
@@ -0,0 +1,98 @@
+package dbmapper
+
+import (
+	"testing"
+)
+
+func TestFragment(t *testing.T) {
+	where := And(
+		Fragment("tenant_id = :tenant"),
+		Fragment("status IN (:status)", Param("status", "active", "pending")),
+		Or(
+			Fragment("name = :name", Param("name", "alice")),
+			Fragment("email = :email", Param("email", "alice@example.com")),
+		),
+	)
+	if where.Error() == nil {
+		t.Errorf("Fail: expect missing tenant parameter error")
+	}
+	where = And(
+		Fragment("tenant_id = :tenant", Param("tenant", 7)),
+		Fragment("status IN (:status)", Param("status", "active", "pending")),
+		Or(
+			Fragment("name = :name", Param("name", "alice")),
+			Fragment("email = :email", Param("email", "alice@example.com")),
+		),
+		Fragment("deleted_at IS NULL"),
+	)
+	expectedWhere := "((tenant_id = ?) AND (status IN (?, ?)) AND ((name = ?) OR (email = ?)) AND (deleted_at IS NULL))"
+	if where.SQL() != expectedWhere {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedWhere, where.SQL())
+	}
+	sub := Fragment("SELECT user_id FROM roles WHERE role = :role", Param("role", "admin"))
+	q := Prepare("SELECT id FROM users WHERE created > :since AND :where AND id IN (:admins) LIMIT :limit", Placeholder(DollarNumbered)).With(
+		Param("limit", 10),
+		Embed("where", where),
+		Embed("admins", sub),
+		Param("since", "2020-01-01"),
+	)
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	expectedSql := "SELECT id FROM users WHERE created > $1 AND ((tenant_id = $2) AND (status IN ($3, $4)) AND ((name = $5) OR (email = $6)) AND (deleted_at IS NULL)) AND id IN (SELECT user_id FROM roles WHERE role = $7) LIMIT $8"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedParams := []interface{}{"2020-01-01", 7, "active", "pending", "alice", "alice@example.com", "admin", 10}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	either := Or(
+		Fragment("a = :a", Param("a", 1)),
+		Fragment("b IN (:b)", Param("b", 2, 3)),
+	)
+	q = Prepare("SELECT id FROM t WHERE :w AND z = :z", Placeholder(DollarNumbered)).With(Param("z", 9), Embed("w", either))
+	expectedSql = "SELECT id FROM t WHERE ((a = $1) OR (b IN ($2, $3))) AND z = $4"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	q = Prepare("SELECT id FROM t WHERE :w AND z = :z", Placeholder(DollarNumbered)).With(Param("z", 9), Embed("w", And(either)))
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	single := And(Fragment("a = :a OR b = :b", Param("a", 1), Param("b", 2)))
+	if single.SQL() != "(a = ? OR b = ?)" {
+		t.Errorf("Fail: expect single fragment wrapped in parentheses, got [ %v ] instead", single.SQL())
+	}
+	q = Prepare("SELECT id FROM t WHERE z = :z AND :w", Placeholder(DollarNumbered)).With(Param("z", 9), Embed("w", single))
+	expectedSql = "SELECT id FROM t WHERE z = $1 AND (a = $2 OR b = $3)"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	for _, q := range []QueryMapper{single, either, Page(either, 10, 0)} {
+		if q.With(Param("a", 1)).Error() == nil {
+			t.Errorf("Fail: expect error binding parameters to [ %v ]", q.SQL())
+		}
+	}
+	q = Prepare("SELECT id FROM t WHERE :w", UseDialect(PostgreSQL)).With(Embed("w", And(
+		Fragment(`p = 'C:\' AND b = :b`, Param("b", 1)),
+		Fragment("c = :c", Param("c", 2)),
+	)))
+	expectedSql = `SELECT id FROM t WHERE ((p = 'C:\' AND b = $1) AND (c = $2))`
+	if q.Error() != nil || q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] with error %v instead", expectedSql, q.SQL(), q.Error())
+	}
+	q = Prepare("SELECT id FROM users WHERE :where").With(Embed("where", And()))
+	if q.SQL() != "SELECT id FROM users WHERE 1 = 1" {
+		t.Errorf("Fail: expect always true predicate, got [ %v ] instead", q.SQL())
+	}
+	q = Prepare("SELECT id FROM users WHERE :where").With(Embed("where", Or(Fragment("id IN (:ids)", OnEmpty(Param("ids"), EmptySkip)))))
+	if q.Error() != nil || !q.Skip() {
+		t.Errorf("Fail: expect skipped fragment to skip the query, got error %v", q.Error())
+	}
+}
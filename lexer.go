@@ -0,0 +1,180 @@
+package dbmapper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// token is a piece of compiled named query, either SQL text or a named
+// parameter reference
+type token struct {
+	text  string
+	param string
+	// pos is byte offset of parameter reference in named query
+	pos int
+	// cond starts a section kept only when the named parameter is given
+	cond string
+	// end closes a conditional section
+	end bool
+}
+
+func isParamStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isParamChar(c byte) bool {
+	return isParamStart(c) || (c >= '0' && c <= '9')
+}
+
+func isParamName(s string) bool {
+	if s == "" || !isParamStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isParamChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lex splits named query into SQL text and parameter tokens. Parameters are
+// only recognised in SQL code positions, so string literals, quoted
+// identifiers, comments, `::type` casts and `:=` assignments are kept as is.
+// A literal colon can be written as `\:`. Sections enclosed in
+// `/*if:name*/` and `/*end*/` comments become conditional tokens. Backslash
+// escapes in quoted strings and `#` comments follow opts dialect, backslash
+// escapes are honoured in PostgreSQL `E'...'` strings regardless
+func lex(namedSql string, opts *Options) ([]token, error) {
+	backslashEscapes, hashComments := opts.backslashEscapes(), opts.hashComments()
+	tokens := make([]token, 0)
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, token{text: text.String()})
+			text.Reset()
+		}
+	}
+	// offsets of open conditional sections
+	sections := make([]int, 0)
+	n := len(namedSql)
+	for i := 0; i < n; {
+		c := namedSql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			escapes := backslashEscapes && c != '`'
+			if c == '\'' && i > 0 && (namedSql[i-1] == 'E' || namedSql[i-1] == 'e') && (i == 1 || !isParamChar(namedSql[i-2])) {
+				escapes = true
+			}
+			end, err := skipQuoted(namedSql, i, escapes)
+			if err != nil {
+				return nil, err
+			}
+			text.WriteString(namedSql[i:end])
+			i = end
+		case (c == '-' && i+1 < n && namedSql[i+1] == '-') || (c == '#' && hashComments):
+			end := strings.IndexByte(namedSql[i:], '\n')
+			if end < 0 {
+				end = n
+			} else {
+				end += i
+			}
+			text.WriteString(namedSql[i:end])
+			i = end
+		case c == '/' && i+1 < n && namedSql[i+1] == '*':
+			end := strings.Index(namedSql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			comment := strings.TrimSpace(namedSql[i+2 : i+2+end])
+			end += i + 4
+			switch {
+			case strings.HasPrefix(comment, "if:") && isParamName(strings.TrimSpace(comment[3:])):
+				flush()
+				tokens = append(tokens, token{cond: strings.TrimSpace(comment[3:]), pos: i})
+				sections = append(sections, i)
+			case comment == "end":
+				if len(sections) == 0 {
+					return nil, fmt.Errorf("/*end*/ without /*if:...*/ at offset %d", i)
+				}
+				flush()
+				tokens = append(tokens, token{end: true, pos: i})
+				sections = sections[:len(sections)-1]
+			default:
+				text.WriteString(namedSql[i:end])
+			}
+			i = end
+		case c == '$':
+			end, err := skipDollarQuoted(namedSql, i)
+			if err != nil {
+				return nil, err
+			}
+			text.WriteString(namedSql[i:end])
+			i = end
+		case c == '\\' && i+1 < n && namedSql[i+1] == ':':
+			text.WriteByte(':')
+			i += 2
+		case c == ':' && i+1 < n && (namedSql[i+1] == ':' || namedSql[i+1] == '='):
+			text.WriteString(namedSql[i : i+2])
+			i += 2
+		case c == ':' && i+1 < n && isParamStart(namedSql[i+1]):
+			end := i + 2
+			for end < n && isParamChar(namedSql[end]) {
+				end++
+			}
+			flush()
+			tokens = append(tokens, token{param: namedSql[i+1 : end], pos: i})
+			i = end
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	if len(sections) > 0 {
+		return nil, fmt.Errorf("unterminated /*if:...*/ section at offset %d", sections[len(sections)-1])
+	}
+	return tokens, nil
+}
+
+// skipQuoted returns offset after the quoted literal or identifier starting
+// at start. Doubled quotes are honoured, backslash escapes only when escapes
+// is set
+func skipQuoted(s string, start int, escapes bool) (int, error) {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c quoted string at offset %d", quote, start)
+}
+
+// skipDollarQuoted returns offset after PostgreSQL `$tag$...$tag$` string
+// starting at start, or start+1 when it is not a dollar quoted string
+func skipDollarQuoted(s string, start int) (int, error) {
+	i := start + 1
+	if i < len(s) && isParamStart(s[i]) {
+		for i < len(s) && isParamChar(s[i]) {
+			i++
+		}
+	}
+	if i >= len(s) || s[i] != '$' {
+		return start + 1, nil
+	}
+	tag := s[start : i+1]
+	end := strings.Index(s[i+1:], tag)
+	if end < 0 {
+		return 0, fmt.Errorf("unterminated %s quoted string at offset %d", tag, start)
+	}
+	return i + 1 + end + len(tag), nil
+}
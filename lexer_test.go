@@ -0,0 +1,145 @@
+package dbmapper
+
+import (
+	"testing"
+)
+
+func TestNamedParameterParser(t *testing.T) {
+	cases := []struct {
+		namedSql    string
+		expectedSql string
+		paramCount  int
+	}{
+		{"select ':name' from test where a = :a", "select ':name' from test where a = ?", 1},
+		{"select 'it''s :x', \"col:x\", `col:y` from test where a = :a", "select 'it''s :x', \"col:x\", `col:y` from test where a = ?", 1},
+		{"select 'it\\'s :x' from test where a = :a", "select 'it\\'s :x' from test where a = ?", 1},
+		{"select a -- where b = :b\nfrom test where a = :a", "select a -- where b = :b\nfrom test where a = ?", 1},
+		{"select a /* :b */ from test where a = :a", "select a /* :b */ from test where a = ?", 1},
+		{"select a::text from test where a = :a::int", "select a::text from test where a = ?::int", 1},
+		{"select @row := @row + 1 from test where a = :a", "select @row := @row + 1 from test where a = ?", 1},
+		{"select $$ :b $$, $tag$ :c $tag$ from test where a = :a", "select $$ :b $$, $tag$ :c $tag$ from test where a = ?", 1},
+		{"select '10\\:30', a from test where t = '10\\:30' and a = :a_1", "select '10\\:30', a from test where t = '10\\:30' and a = ?", 1},
+		{"select a from test where t = 10\\:30 and a = :Ab_1", "select a from test where t = 10:30 and a = ?", 1},
+		{"select a from test where a = :1", "select a from test where a = :1", 0},
+	}
+	for _, c := range cases {
+		q := Prepare(c.namedSql)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error for [ %v ], got %v instead", c.namedSql, q.Error())
+			continue
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+		params := make([]Parameter, 0)
+		for i := 0; i < c.paramCount; i++ {
+			params = append(params, Param("a", 1), Param("a_1", 1), Param("Ab_1", 1))
+		}
+		q = q.With(params...)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error for [ %v ], got %v instead", c.namedSql, q.Error())
+		}
+		if len(q.Params()) != c.paramCount {
+			t.Errorf("Fail: expect %v parameters, got %v instead", c.paramCount, q.Params())
+		}
+	}
+}
+
+func TestNamedParameterParserError(t *testing.T) {
+	for _, namedSql := range []string{
+		"select 'abc from test where a = :a",
+		"select a /* from test where a = :a",
+		"select $x$ a from test where a = :a",
+	} {
+		if q := Prepare(namedSql); q.Error() == nil {
+			t.Errorf("Fail: expect error for [ %v ], got [ %v ] instead", namedSql, q.SQL())
+		}
+	}
+}
+
+func TestBackslashEscapes(t *testing.T) {
+	cases := []struct {
+		namedSql    string
+		dialect     *Dialect
+		expectedSql string
+	}{
+		{`SELECT 'C:\' AS p, x FROM t WHERE x = :x`, PostgreSQL, `SELECT 'C:\' AS p, x FROM t WHERE x = $1`},
+		{`SELECT E'it\'s :y', x FROM t WHERE x = :x`, PostgreSQL, `SELECT E'it\'s :y', x FROM t WHERE x = $1`},
+		{`SELECT 'it\'s :y', x FROM t WHERE x = :x`, MySQL, `SELECT 'it\'s :y', x FROM t WHERE x = ?`},
+		{"SELECT 1 # :y\nFROM t WHERE x = :x", MySQL, "SELECT 1 # :y\nFROM t WHERE x = ?"},
+		{"SELECT 1 # :y\nFROM t WHERE x = :x", nil, "SELECT 1 # :y\nFROM t WHERE x = ?"},
+		{"SELECT a # b FROM t WHERE x = :x", PostgreSQL, "SELECT a # b FROM t WHERE x = $1"},
+	}
+	for _, c := range cases {
+		q := Prepare(c.namedSql)
+		if c.dialect != nil {
+			q = Prepare(c.namedSql, UseDialect(c.dialect))
+		}
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error for [ %v ], got %v instead", c.namedSql, q.Error())
+			continue
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+		if q = q.With(Param("x", 1)); q.Error() != nil {
+			t.Errorf("Fail: expect no error binding [ %v ], got %v instead", c.namedSql, q.Error())
+		}
+	}
+	if q := Prepare(`SELECT 'C:\' AS p, x FROM t WHERE x = :x`, UseDialect(MySQL)); q.Error() == nil {
+		t.Errorf("Fail: expect unterminated string error for MySQL, got [ %v ] instead", q.SQL())
+	}
+}
+
+func TestConditionalSection(t *testing.T) {
+	search := Prepare("SELECT id FROM users WHERE tenant_id = :tenant /*if:name*/ AND name = :name /*end*/" +
+		"/*if: roles */ AND role IN (:roles) /*if:admin*/ AND admin = :admin/*end*/ /*end*/ ORDER BY id")
+	if search.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", search.Error())
+	}
+	cases := []struct {
+		params      []Parameter
+		expectedSql string
+	}{
+		{
+			[]Parameter{Param("tenant", 1)},
+			"SELECT id FROM users WHERE tenant_id = ?  ORDER BY id",
+		},
+		{
+			[]Parameter{Param("tenant", 1), Param("name", "alice")},
+			"SELECT id FROM users WHERE tenant_id = ?  AND name = ?  ORDER BY id",
+		},
+		{
+			[]Parameter{Param("tenant", 1), Param("roles", "a", "b"), Param("admin", true)},
+			"SELECT id FROM users WHERE tenant_id = ?  AND role IN (?, ?)  AND admin = ?  ORDER BY id",
+		},
+		{
+			[]Parameter{Param("tenant", 1), Param("admin", true)},
+			"SELECT id FROM users WHERE tenant_id = ?  ORDER BY id",
+		},
+	}
+	for _, c := range cases {
+		q := search.With(c.params...)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error, got %v instead", q.Error())
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+	}
+	strict := Prepare("SELECT id FROM users WHERE tenant_id = :tenant /*if:name*/ AND name = :name /*end*/", StrictParams())
+	if q := strict.With(Param("tenant", 1)); q.Error() != nil {
+		t.Errorf("Fail: expect no error, got %v instead", q.Error())
+	}
+	for _, namedSql := range []string{
+		"SELECT id FROM users /*if:name*/ WHERE name = :name",
+		"SELECT id FROM users WHERE name = :name /*end*/",
+	} {
+		if q := Prepare(namedSql); q.Error() == nil {
+			t.Errorf("Fail: expect unbalanced section error for [ %v ]", namedSql)
+		}
+	}
+	if q := Prepare("SELECT id /* if:name is a comment */ FROM users"); q.SQL() != "SELECT id /* if:name is a comment */ FROM users" {
+		t.Errorf("Fail: expect plain comment to be kept, got [ %v ] instead", q.SQL())
+	}
+}
@@ -0,0 +1,70 @@
+package dbmapper
+
+import (
+	"sync"
+)
+
+// Level is log severity
+type Level int
+
+const (
+	// LevelDebug is verbose diagnostic output
+	LevelDebug Level = iota
+	// LevelInfo is informational output
+	LevelInfo
+	// LevelWarn reports recoverable problems
+	LevelWarn
+	// LevelError reports failures
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// Field is a structured log attribute
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives log entries written by dbmapper
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// LoggerFunc adapts a function to Logger
+type LoggerFunc func(level Level, msg string, fields ...Field)
+
+// Log calls f
+func (f LoggerFunc) Log(level Level, msg string, fields ...Field) {
+	f(level, msg, fields...)
+}
+
+var (
+	loggerMu sync.RWMutex
+	logger   Logger
+)
+
+// SetLogger sets the global logger used by queries and mappers without their
+// own logger. Passing nil silences logging, which is the default
+func SetLogger(l Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = l
+}
+
+func globalLogger() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return logger
+}
@@ -0,0 +1,46 @@
+package dbmapper
+
+import (
+	"testing"
+)
+
+type entry struct {
+	level  Level
+	msg    string
+	fields []Field
+}
+
+type recordingLogger struct {
+	entries []entry
+}
+
+func (l *recordingLogger) Log(level Level, msg string, fields ...Field) {
+	l.entries = append(l.entries, entry{level, msg, fields})
+}
+
+func TestQueryLogger(t *testing.T) {
+	global := &recordingLogger{}
+	local := &recordingLogger{}
+	SetLogger(global)
+	defer SetLogger(nil)
+	q := Prepare("select id from test where id = :id", UseLogger(local)).With()
+	if q.SQL() != "" {
+		t.Errorf("Fail: expect empty sql string, got [ %v ] instead", q.SQL())
+	}
+	if len(local.entries) != 1 || len(global.entries) != 0 {
+		t.Fatalf("Fail: expect a single entry in query logger, got %v and %v instead", local.entries, global.entries)
+	}
+	if local.entries[0].level != LevelWarn || local.entries[0].fields[0].Value != q.Error() {
+		t.Errorf("Fail: expect warning with query error, got %+v instead", local.entries[0])
+	}
+	q = Prepare("select id from test where id = :id").With()
+	q.ParamNames()
+	if len(global.entries) != 1 {
+		t.Errorf("Fail: expect a single entry in global logger, got %v instead", global.entries)
+	}
+	SetLogger(nil)
+	q.SQL()
+	if len(global.entries) != 1 {
+		t.Errorf("Fail: expect logging to be silent by default, got %v instead", global.entries)
+	}
+}
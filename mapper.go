@@ -1,9 +1,9 @@
 package dbmapper
 
 import (
-	"errors"
-	"log"
-	"regexp"
+	"database/sql"
+	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -31,11 +31,14 @@ type QueryMapper interface {
 	SQL() string
 	ParamNames() []string
 	Error() error
+	Batches() []QueryMapper
+	Skip() bool
+	Interpolated(*Dialect) string
 }
 
 // ResultMapper is database result set mapper
 type ResultMapper interface {
-	Map(RowMapper) error
+	Map(RowMapper, ...Option) error
 }
 
 // Int32 returns a row mapper for single int32 column
@@ -79,11 +82,29 @@ func Int64(columnName string, dst *[]int64) RowMapper {
 
 type query struct {
 	namedSql    string
+	tokens      []token
+	pieces      []piece
 	sql         string
-	params      map[string]interface{}
 	paramValues []interface{}
 	paramNames  []string
-	err         error
+	// refAt is index in pieces where each reference of paramNames starts
+	refAt   []int
+	batches []QueryMapper
+	skip    bool
+	// pageAt is index of pieces appended by Page, 0 when not paged
+	pageAt int
+	// page is reapplied by With to bind a paged query again, nil when not
+	// paged
+	page *pageSpec
+	// counted is set by Count, With derives the count from the bound query
+	counted bool
+	// grouped is set when And or Or enclosed the pieces in parentheses
+	grouped bool
+	// rebind returns the fragment lexed and bound again with options of the
+	// query embedding it, nil when not a fragment
+	rebind func(o *Options) QueryMapper
+	opts   *Options
+	err    error
 }
 
 func (q *query) Error() error {
@@ -96,7 +117,7 @@ func (q *query) Params() []interface{} {
 
 func (q *query) SQL() string {
 	if q.err != nil {
-		log.Printf("Failed to get query string, %v", q.err)
+		q.opts.Log(LevelWarn, "failed to get query string", Field{"error", q.err}, Field{"query", q.namedSql})
 		return ""
 	}
 	return q.sql
@@ -104,70 +125,451 @@ func (q *query) SQL() string {
 
 func (q *query) ParamNames() []string {
 	if q.err != nil {
-		log.Printf("Failed to get query string, %v", q.err)
+		q.opts.Log(LevelWarn, "failed to get query parameter names", Field{"error", q.err}, Field{"query", q.namedSql})
 		return make([]string, 0)
 	}
 	return q.paramNames
 }
 
-func (q *query) getParameter(name string, params []Parameter) ([]interface{}, error) {
+// Skip reports whether the query should not be executed because a parameter
+// with EmptySkip policy has no values. Its result is known to be empty
+func (q *query) Skip() bool {
+	return q.skip
+}
+
+// Batches returns the bound query split into several queries, each within
+// the dialect maximum placeholder count. It returns the query itself when no
+// split is needed
+func (q *query) Batches() []QueryMapper {
+	if q.batches == nil {
+		return []QueryMapper{q}
+	}
+	return q.batches
+}
+
+func (q *query) getParameter(name string, params []Parameter) Parameter {
 	for _, p := range params {
 		if name == p.Name() {
-			return p.Value()
+			return p
 		}
 	}
-	return nil, nil
+	return nil
 }
 
-func (q *query) With(parameters ...Parameter) QueryMapper {
-	q.sql = q.namedSql
-	if q.params == nil {
-		q.params = make(map[string]interface{})
+// bind returns pieces rendering parameter p. skip reports that the query
+// should not be executed because p has no values
+func (q *query) bind(p Parameter) (pieces []piece, skip bool, err error) {
+	policy := EmptyError
+	secret := false
+	for unwrapped := false; !unwrapped; {
+		switch w := p.(type) {
+		case *emptyParameter:
+			p, policy = w.Parameter, w.policy
+		case *secretParameter:
+			p, secret = w.Parameter, true
+		default:
+			unwrapped = true
+		}
+	}
+	if e, ok := p.(*embedParameter); ok {
+		var f *query
+		if f, err = fragmentFor(e.q, q.opts); err == nil {
+			pieces, skip = f.pieces, f.skip
+			if len(pieces) == 0 {
+				pieces = []piece{{text: ""}}
+			}
+		}
+	} else if e, ok := p.(expander); ok {
+		pieces, err = e.expand(q.opts)
+	} else {
+		var value []interface{}
+		value, err = p.Value()
+		pieces = argList(p.Name(), value)
+		if q.opts.NamedArgs && len(value) > 1 {
+			// Number each value of a list, as drivers bind scalar named
+			// arguments only
+			for i, idx := 0, 1; i < len(pieces); i, idx = i+2, idx+1 {
+				pieces[i].arg.name = p.Name() + "_" + strconv.Itoa(idx)
+			}
+		}
+	}
+	if secret {
+		for _, pc := range pieces {
+			if pc.arg != nil {
+				pc.arg.secret = true
+			}
+		}
 	}
-	pattern, err := regexp.Compile(":([a-zA-Z0-9_]+)")
+	if err != nil || len(pieces) > 0 {
+		return
+	}
+	switch policy {
+	case EmptyNull, EmptySkip:
+		pieces = []piece{{text: "NULL"}}
+		if r, ok := p.(*rowsParameter); ok && r.wrap {
+			pieces = []piece{{text: "(NULL)"}}
+		}
+		skip = policy == EmptySkip
+	default:
+		err = &EmptyParamError{Name: p.Name()}
+	}
+	return
+}
+
+// render builds SQL string and parameter values from bound pieces
+func (q *query) render() {
+	if q.opts.NamedArgs {
+		q.renderNamed()
+		return
+	}
+	var sql strings.Builder
+	for _, pc := range q.pieces {
+		if pc.ident != "" {
+			sql.WriteString(q.opts.Dialect.QuoteIdent(pc.ident))
+			continue
+		}
+		if pc.arg == nil {
+			sql.WriteString(pc.text)
+			continue
+		}
+		value, err := q.value(pc.arg)
+		if err != nil {
+			q.err = err
+			return
+		}
+		q.paramValues = append(q.paramValues, value)
+		sql.WriteString(q.opts.Placeholder.render(len(q.paramValues)))
+		sql.WriteString(q.escapeClause(pc.arg))
+	}
+	q.sql = sql.String()
+}
+
+// escapeClause returns ESCAPE clause following LIKE pattern argument
+func (q *query) escapeClause(a *arg) string {
+	if a.like == likeNone {
+		return ""
+	}
+	_, clause := q.opts.Dialect.likePattern(a.like, "")
+	return clause
+}
+
+// value returns arg value converted for the query dialect
+func (q *query) value(a *arg) (interface{}, error) {
+	if a.like != likeNone {
+		value, _ := q.opts.Dialect.likePattern(a.like, a.value.(string))
+		return value, nil
+	}
+	value, err := convertValue(q.opts.Dialect, a.value)
 	if err != nil {
-		q.err = err
-		return q
+		return nil, &ParamValueError{Name: a.name, Err: err}
+	}
+	return value, nil
+}
+
+// renderNamed builds SQL string keeping named parameters and parameter
+// values as sql.NamedArg. Every bound parameter is passed once, distinct
+// parameters sharing a name are suffixed with a counter
+func (q *query) renderNamed() {
+	prefix := "@"
+	if d := q.opts.Dialect; d != nil && d.NamedPrefix != "" {
+		prefix = d.NamedPrefix
+	}
+	q.paramNames = make([]string, 0)
+	names := make(map[*arg]string)
+	counts := make(map[string]int)
+	var named strings.Builder
+	for _, pc := range q.pieces {
+		switch {
+		case pc.ident != "":
+			named.WriteString(q.opts.Dialect.QuoteIdent(pc.ident))
+		case pc.arg == nil:
+			named.WriteString(pc.text)
+		default:
+			name, ok := names[pc.arg]
+			if !ok {
+				name = argName(pc.arg.name)
+				counts[name]++
+				if counts[name] > 1 {
+					name += "_" + strconv.Itoa(counts[name])
+				}
+				value, err := q.value(pc.arg)
+				if err != nil {
+					q.err = err
+					return
+				}
+				names[pc.arg] = name
+				q.paramNames = append(q.paramNames, name)
+				q.paramValues = append(q.paramValues, sql.Named(name, value))
+			}
+			named.WriteString(prefix + name)
+			named.WriteString(q.escapeClause(pc.arg))
+		}
 	}
-	res := pattern.FindAllStringSubmatch(q.namedSql, -1)
-	for _, match := range res {
-		if len(match) == 2 {
-			paramName := match[1]
-			value, err := q.getParameter(paramName, parameters)
+	q.sql = named.String()
+}
+
+// argName returns name usable as named parameter
+func argName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !isParamChar(c) {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || !isParamStart(b[0]) {
+		return "p" + string(b)
+	}
+	return string(b)
+}
+
+// Interpolated returns the query with parameter values rendered as literals
+// of dialect d, or of the query dialect when d is nil. Values of Secret
+// parameters are rendered as ***. The result is meant for logging only and
+// must never be executed
+func (q *query) Interpolated(d *Dialect) string {
+	if q.err != nil {
+		return ""
+	}
+	if d == nil {
+		d = q.opts.Dialect
+	}
+	if q.pieces == nil {
+		return q.sql
+	}
+	var sql strings.Builder
+	for _, pc := range q.pieces {
+		switch {
+		case pc.ident != "":
+			sql.WriteString(d.QuoteIdent(pc.ident))
+		case pc.arg == nil:
+			sql.WriteString(pc.text)
+		case pc.arg.secret:
+			sql.WriteString("***")
+		case pc.arg.like != likeNone:
+			pattern, clause := d.likePattern(pc.arg.like, pc.arg.value.(string))
+			sql.WriteString(d.Literal(pattern) + clause)
+		default:
+			value, err := convertValue(d, pc.arg.value)
 			if err != nil {
-				q.err = err
-				return q
+				return ""
 			}
-			if len(value) > 1 {
-				q.paramNames = append(q.paramNames, paramName)
-				sliceElmts := []string{}
-				for idx, elmt := range value {
-					q.params[paramName+"_"+string(idx)] = elmt
-					q.paramValues = append(q.paramValues, elmt)
-					sliceElmts = append(sliceElmts, "?")
-				}
-				q.sql = strings.Replace(q.sql, ":"+paramName, strings.Join(sliceElmts, ", "), 1)
-			} else if len(value) == 1 {
-				q.paramNames = append(q.paramNames, paramName)
-				q.params[paramName] = value[0]
-				q.paramValues = append(q.paramValues, value[0])
-				q.sql = strings.Replace(q.sql, ":"+paramName, "?", 1)
+			sql.WriteString(d.Literal(value))
+		}
+	}
+	return sql.String()
+}
+
+// With binds parameters to the prepared query. The receiver is never
+// modified, every call returns a new bound query so a prepared query can be
+// shared and bound concurrently
+func (q *query) With(parameters ...Parameter) QueryMapper {
+	if q.err != nil {
+		return q
+	}
+	if q.tokens == nil {
+		// Built by And, Or, Keyset or Page over them, there is no template
+		// to bind
+		err := fmt.Errorf("cannot bind parameters to %q, it is not a prepared query", q.sql)
+		return &query{namedSql: q.namedSql, opts: q.opts, paramNames: make([]string, 0), err: err}
+	}
+	bound := q.bindAll(parameters)
+	if bound.err == nil && q.page != nil {
+		bound = Page(bound, q.page.limit, q.page.offset).(*query)
+	}
+	if bound.err == nil && q.counted {
+		bound = Count(bound).(*query)
+	}
+	if d := q.opts.Dialect; bound.err == nil && d != nil && d.MaxParams > 0 && len(bound.paramValues) > d.MaxParams {
+		bound.batches, bound.err = q.split(parameters, d.MaxParams)
+	}
+	return bound
+}
+
+// bindAll returns a new query with parameters bound to the template
+func (q *query) bindAll(parameters []Parameter) *query {
+	bound := &query{
+		namedSql:   q.namedSql,
+		tokens:     q.tokens,
+		opts:       q.opts,
+		paramNames: make([]string, 0),
+	}
+	// A parameter may be referenced more than once, resolve its value once
+	// and bind it at every position
+	values := make(map[string][]piece)
+	used := make(map[string]bool)
+	errs := make(ParamErrors, 0)
+	// depth of conditional section being dropped, 0 when none
+	dropped := 0
+	for _, tok := range q.tokens {
+		switch {
+		case tok.cond != "":
+			used[tok.cond] = true
+			if dropped > 0 || q.getParameter(tok.cond, parameters) == nil {
+				dropped++
+			}
+			continue
+		case tok.end:
+			if dropped > 0 {
+				dropped--
+			}
+			continue
+		case dropped > 0:
+			if tok.param != "" {
+				used[tok.param] = true
+			}
+			continue
+		case tok.param == "":
+			bound.pieces = append(bound.pieces, piece{text: tok.text})
+			continue
+		}
+		used[tok.param] = true
+		value, ok := values[tok.param]
+		if !ok {
+			if p := q.getParameter(tok.param, parameters); p == nil {
+				errs = append(errs, &MissingParamError{Name: tok.param, Position: tok.pos})
 			} else {
-				q.err = errors.New("Missing paramters")
-				return q
+				var skip bool
+				var err error
+				value, skip, err = q.bind(p)
+				if _, empty := err.(*EmptyParamError); empty {
+					errs = append(errs, err)
+				} else if err != nil {
+					bound.err = &ParamValueError{Name: tok.param, Err: err}
+					return bound
+				}
+				bound.skip = bound.skip || skip
 			}
-		} else {
+			values[tok.param] = value
+		}
+		if len(value) == 0 {
 			continue
 		}
+		bound.paramNames = append(bound.paramNames, tok.param)
+		bound.refAt = append(bound.refAt, len(bound.pieces))
+		bound.pieces = append(bound.pieces, value...)
 	}
-	return q
+	if q.opts.Strict {
+		for _, p := range parameters {
+			if !used[p.Name()] {
+				errs = append(errs, &UnusedParamError{Name: p.Name()})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		bound.err = errs.err()
+		return bound
+	}
+	bound.render()
+	return bound
+}
+
+// split binds parameters in several queries, each within max placeholders,
+// by distributing rows of the bulk parameter between them
+func (q *query) split(parameters []Parameter, max int) ([]QueryMapper, error) {
+	var bulk *rowsParameter
+	idx := -1
+	for i, p := range parameters {
+		if r, ok := unwrapParameter(p).(*rowsParameter); ok && q.references(r.name) {
+			if bulk != nil {
+				return nil, fmt.Errorf("cannot split query with more than one bulk parameter, found %s and %s", bulk.name, r.name)
+			}
+			bulk, idx = r, i
+		}
+	}
+	if bulk == nil || len(bulk.rows) < 2 {
+		return nil, fmt.Errorf("query binds more than %d placeholders", max)
+	}
+	// Measure placeholders taken by a single row and by the rest of the query
+	params := make([]Parameter, len(parameters))
+	copy(params, parameters)
+	count := func(rows [][]interface{}) int {
+		params[idx] = withRows(parameters[idx], rows)
+		return len(q.bindAll(params).paramValues)
+	}
+	one, two := count(bulk.rows[:1]), count(bulk.rows[:2])
+	perRow := two - one
+	if perRow < 1 {
+		return nil, fmt.Errorf("query binds more than %d placeholders, rows of %s bind none", max, bulk.name)
+	}
+	size := (max - (one - perRow)) / perRow
+	if size < 1 {
+		return nil, fmt.Errorf("query binds more than %d placeholders for a single row of %s", max, bulk.name)
+	}
+	batches := make([]QueryMapper, 0, len(bulk.rows)/size+1)
+	for start := 0; start < len(bulk.rows); start += size {
+		end := start + size
+		if end > len(bulk.rows) {
+			end = len(bulk.rows)
+		}
+		params[idx] = withRows(parameters[idx], bulk.rows[start:end])
+		batch := q.bindAll(params)
+		if batch.err != nil {
+			return nil, batch.err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// unwrapParameter returns p without OnEmpty and Secret wrappers
+func unwrapParameter(p Parameter) Parameter {
+	for {
+		switch w := p.(type) {
+		case *emptyParameter:
+			p = w.Parameter
+		case *secretParameter:
+			p = w.Parameter
+		default:
+			return p
+		}
+	}
+}
+
+// withRows returns p, a possibly wrapped rowsParameter, binding rows instead
+func withRows(p Parameter, rows [][]interface{}) Parameter {
+	switch w := p.(type) {
+	case *emptyParameter:
+		return &emptyParameter{withRows(w.Parameter, rows), w.policy}
+	case *secretParameter:
+		return &secretParameter{withRows(w.Parameter, rows)}
+	case *rowsParameter:
+		return &rowsParameter{w.name, rows, w.wrap}
+	}
+	return p
+}
+
+// references reports whether the query uses named parameter
+func (q *query) references(name string) bool {
+	for _, tok := range q.tokens {
+		if tok.param == name {
+			return true
+		}
+	}
+	return false
 }
 
-// Prepare
-func Prepare(namedSql string) QueryMapper {
-	pattern := regexp.MustCompile(":([a-z0-9-_]+)")
-	sql := pattern.ReplaceAllString(namedSql, "?")
-	return &query{namedSql: namedSql, sql: sql, paramNames: make([]string, 0)}
+// Prepare compiles named query into an immutable template, safe to be
+// declared as package level variable and bound from multiple goroutines.
+// Named parameters are rendered using placeholder style from opts, `?` by
+// default. A section enclosed in `/*if:name*/` and `/*end*/` is kept only
+// when parameter name is given to QueryMapper.With. Backslash escapes in
+// string literals follow the dialect, and are honoured when it is not given
+func Prepare(namedSql string, opts ...Option) QueryMapper {
+	o := NewOptions(opts...)
+	q := &query{namedSql: namedSql, opts: o, paramNames: make([]string, 0)}
+	q.tokens, q.err = lex(namedSql, o)
+	var sql strings.Builder
+	n := 0
+	for _, tok := range q.tokens {
+		if tok.param != "" {
+			n++
+			sql.WriteString(o.Placeholder.render(n))
+		} else {
+			sql.WriteString(tok.text)
+		}
+	}
+	q.sql = sql.String()
+	return q
 }
 
 type parameter struct {
@@ -0,0 +1,179 @@
+package dbmapper
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+)
+
+func TestPlaceholderStyle(t *testing.T) {
+	namedSql := "select id from test where id IN (:ids) and name = :name"
+	cases := []struct {
+		style       PlaceholderStyle
+		expectedSql string
+	}{
+		{QuestionMark, "select id from test where id IN (?, ?, ?) and name = ?"},
+		{DollarNumbered, "select id from test where id IN ($1, $2, $3) and name = $4"},
+		{AtNumbered, "select id from test where id IN (@p1, @p2, @p3) and name = @p4"},
+		{ColonNumbered, "select id from test where id IN (:1, :2, :3) and name = :4"},
+	}
+	for _, c := range cases {
+		q := Prepare(namedSql, Placeholder(c.style)).With(
+			Param("ids", 1, 2, 3),
+			Param("name", "alice"),
+		)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error, got %v instead", q.Error())
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+		expectedParams := []interface{}{1, 2, 3, "alice"}
+		if len(q.Params()) != len(expectedParams) {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+			continue
+		}
+		for idx, p := range q.Params() {
+			if expectedParams[idx] != p {
+				t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+			}
+		}
+	}
+}
+
+func TestPreparePlaceholderStyle(t *testing.T) {
+	q := Prepare("select id from test where a = :a and b = :b", Placeholder(DollarNumbered))
+	expectedSql := "select id from test where a = $1 and b = $2"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+}
+
+type countingParameter struct {
+	calls int
+}
+
+func (p *countingParameter) Name() string {
+	return "x"
+}
+
+func (p *countingParameter) Value() ([]interface{}, error) {
+	p.calls++
+	return []interface{}{p.calls}, nil
+}
+
+func TestRepeatedParameter(t *testing.T) {
+	namedSql := "select id from test where a IN (:ids) or b IN (:ids) or c = :name or d = :name"
+	q := Prepare(namedSql, Placeholder(DollarNumbered)).With(
+		Param("ids", 1, 2),
+		Param("name", "alice"),
+	)
+	expectedSql := "select id from test where a IN ($1, $2) or b IN ($3, $4) or c = $5 or d = $6"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedParams := []interface{}{1, 2, 1, 2, "alice", "alice"}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	expectedParamNames := []string{"ids", "ids", "name", "name"}
+	if len(q.ParamNames()) != len(expectedParamNames) {
+		t.Fatalf("Fail: expect %v parameter names, got %v instead", expectedParamNames, q.ParamNames())
+	}
+	for idx, name := range q.ParamNames() {
+		if expectedParamNames[idx] != name {
+			t.Errorf("Fail: expect %v parameter names, got %v instead", expectedParamNames, q.ParamNames())
+		}
+	}
+	p := &countingParameter{}
+	q = Prepare("select id from test where a = :x or b = :x").With(p)
+	if p.calls != 1 {
+		t.Errorf("Fail: expect parameter value resolved once, got %v calls instead", p.calls)
+	}
+	if len(q.Params()) != 2 || q.Params()[0] != q.Params()[1] {
+		t.Errorf("Fail: expect the same value at every position, got %v instead", q.Params())
+	}
+}
+
+var findUsers = Prepare("select id from test where id IN (:ids) and name = :name")
+
+func TestImmutableQueryMapper(t *testing.T) {
+	first := findUsers.With(Param("ids", 1, 2), Param("name", "alice"))
+	second := findUsers.With(Param("ids", 3), Param("name", "bob"))
+	if len(first.Params()) != 3 || len(second.Params()) != 2 {
+		t.Errorf("Fail: expect bound parameters not to accumulate, got %v and %v instead", first.Params(), second.Params())
+	}
+	if len(findUsers.Params()) != 0 || len(findUsers.ParamNames()) != 0 {
+		t.Errorf("Fail: expect prepared query to stay unbound, got %v instead", findUsers.Params())
+	}
+	expectedSql := "select id from test where id IN (?) and name = ?"
+	if findUsers.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, findUsers.SQL())
+	}
+	rebound := first.With(Param("ids", 4), Param("name", "charlie"))
+	if len(rebound.Params()) != 2 || len(first.Params()) != 3 {
+		t.Errorf("Fail: expect rebinding to start from the template, got %v and %v instead", rebound.Params(), first.Params())
+	}
+}
+
+func TestConcurrentQueryMapper(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := findUsers.With(Param("ids", i, i+1), Param("name", i))
+			if len(q.Params()) != 3 || q.Params()[0] != i || q.Params()[2] != i {
+				t.Errorf("Fail: expect [%v %v %v] parameters, got %v instead", i, i+1, i, q.Params())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNamedArgs(t *testing.T) {
+	namedSql := "select id from test where id IN (:ids) and name = :name or alias = :name and :where"
+	q := Prepare(namedSql, UseDialect(SQLServer), NamedArgs()).With(
+		Param("ids", 1, 2, 3),
+		Param("name", "alice"),
+		Embed("where", Fragment("name <> :name", Param("name", "bob"))),
+	)
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	expectedSql := "select id from test where id IN (@ids_1, @ids_2, @ids_3) and name = @name or alias = @name and name <> @name_2"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedNames := []string{"ids_1", "ids_2", "ids_3", "name", "name_2"}
+	if len(q.Params()) != len(expectedNames) || len(q.ParamNames()) != len(expectedNames) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedNames, q.Params())
+	}
+	for idx, p := range q.Params() {
+		named, ok := p.(sql.NamedArg)
+		if !ok || named.Name != expectedNames[idx] || q.ParamNames()[idx] != expectedNames[idx] {
+			t.Errorf("Fail: expect %v named parameters, got %v instead", expectedNames, q.Params())
+		}
+	}
+	for idx, id := range []interface{}{1, 2, 3} {
+		if q.Params()[idx].(sql.NamedArg).Value != id {
+			t.Errorf("Fail: expect ids bound as separate named arguments, got %v instead", q.Params())
+		}
+	}
+	if q.Params()[4].(sql.NamedArg).Value != "bob" {
+		t.Errorf("Fail: expect name_2 bound to bob, got %v instead", q.Params()[4])
+	}
+	expectedInterpolated := "select id from test where id IN (1, 2, 3) and name = N'alice' or alias = N'alice' and name <> N'bob'"
+	if q.Interpolated(nil) != expectedInterpolated {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedInterpolated, q.Interpolated(nil))
+	}
+	q = Prepare("select id from test where id = :id", UseDialect(Oracle), NamedArgs()).With(Param("id", 1))
+	if q.SQL() != "select id from test where id = :id" {
+		t.Errorf("Fail: expect oracle named parameter, got [ %v ] instead", q.SQL())
+	}
+}
@@ -0,0 +1,162 @@
+package dbmapper
+
+import (
+	"strconv"
+)
+
+// PlaceholderStyle is the bind parameter notation used in rendered SQL
+type PlaceholderStyle int
+
+const (
+	// QuestionMark renders every parameter as `?` (MySQL, Cassandra, SQLite)
+	QuestionMark PlaceholderStyle = iota
+	// DollarNumbered renders parameters as `$1`, `$2`, ... (PostgreSQL)
+	DollarNumbered
+	// AtNumbered renders parameters as `@p1`, `@p2`, ... (SQL Server)
+	AtNumbered
+	// ColonNumbered renders parameters as `:1`, `:2`, ... (Oracle)
+	ColonNumbered
+)
+
+// render returns placeholder for the pos-th (1 based) bound value
+func (s PlaceholderStyle) render(pos int) string {
+	switch s {
+	case DollarNumbered:
+		return "$" + strconv.Itoa(pos)
+	case AtNumbered:
+		return "@p" + strconv.Itoa(pos)
+	case ColonNumbered:
+		return ":" + strconv.Itoa(pos)
+	default:
+		return "?"
+	}
+}
+
+// Options holds settings used to render queries and map results
+type Options struct {
+	// Placeholder is the bind parameter notation used in rendered SQL
+	Placeholder PlaceholderStyle
+	// Dialect of the database, nil when unspecified
+	Dialect *Dialect
+	// Strict rejects parameters which are not referenced by the query
+	Strict bool
+	// Logger receives log entries, the global logger is used when nil
+	Logger Logger
+	// NamedArgs keeps named parameters in rendered SQL and returns
+	// parameters as sql.NamedArg
+	NamedArgs bool
+	// ReuseRow calls RowMapper once and scans every row into the same
+	// targets instead of calling it for each row
+	ReuseRow bool
+	// MissingColumns controls mapped columns absent from the result
+	MissingColumns ColumnPolicy
+	// UnmappedColumns controls result columns without a mapped column
+	UnmappedColumns ColumnPolicy
+}
+
+// Option configures Options
+type Option func(*Options)
+
+// withOptions replaces all options with a copy of o
+func withOptions(o *Options) Option {
+	return func(opts *Options) {
+		*opts = *o
+	}
+}
+
+// NewOptions returns default Options with opts applied
+func NewOptions(opts ...Option) *Options {
+	o := &Options{Placeholder: QuestionMark}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Placeholder sets bind parameter notation used in rendered SQL
+func Placeholder(style PlaceholderStyle) Option {
+	return func(o *Options) {
+		o.Placeholder = style
+	}
+}
+
+// UseDialect renders queries for dialect d
+func UseDialect(d *Dialect) Option {
+	return func(o *Options) {
+		o.Dialect = d
+		o.Placeholder = d.Placeholder
+	}
+}
+
+// StrictParams rejects parameters passed to QueryMapper.With which are not
+// referenced by the query
+func StrictParams() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+// UseLogger sends log entries of a query or mapper to l instead of the
+// global logger
+func UseLogger(l Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// backslashEscapes reports whether backslash escapes string literals, which
+// is assumed when no dialect is given
+func (o *Options) backslashEscapes() bool {
+	return o.Dialect == nil || o.Dialect.BackslashEscapes
+}
+
+// hashComments reports whether `#` starts a line comment, which is assumed
+// when no dialect is given
+func (o *Options) hashComments() bool {
+	return o.Dialect == nil || o.Dialect.HashComments
+}
+
+// Log writes entry to the configured logger, if any
+func (o *Options) Log(level Level, msg string, fields ...Field) {
+	l := o.Logger
+	if l == nil {
+		l = globalLogger()
+	}
+	if l != nil {
+		l.Log(level, msg, fields...)
+	}
+}
+
+// NamedArgs keeps named parameters in rendered SQL, prefixed according to the
+// dialect (`@name` by default), and returns parameters as sql.NamedArg for
+// drivers binding named parameters natively. A parameter with multiple
+// values is passed as a single []interface{} argument
+func NamedArgs() Option {
+	return func(o *Options) {
+		o.NamedArgs = true
+	}
+}
+
+// ReuseRow makes result mappers call the RowMapper once and scan every row
+// into the same targets, saving an allocation per row. Then must copy the
+// row, pointers, slices and maps inside it are shared by every row
+func ReuseRow() Option {
+	return func(o *Options) {
+		o.ReuseRow = true
+	}
+}
+
+// CheckColumns sets how result mappers handle mapped columns missing from the
+// result and result columns which are not mapped
+func CheckColumns(missing, unmapped ColumnPolicy) Option {
+	return func(o *Options) {
+		o.MissingColumns = missing
+		o.UnmappedColumns = unmapped
+	}
+}
+
+// StrictColumns fails result mapping with ColumnMismatchError unless mapped
+// columns and result columns match exactly
+func StrictColumns() Option {
+	return CheckColumns(ColumnError, ColumnError)
+}
@@ -0,0 +1,353 @@
+package dbmapper
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// pageSpec is the limit and offset of a paged query
+type pageSpec struct {
+	limit  int
+	offset int
+}
+
+// Page returns query q limited to limit rows starting from offset, using the
+// paging syntax of q dialect. Binding the result again with With keeps the
+// paging
+func Page(q QueryMapper, limit, offset int) QueryMapper {
+	base, err := fragmentPieces(q)
+	if err != nil {
+		return &query{opts: NewOptions(), paramNames: make([]string, 0), err: err}
+	}
+	paged := &query{
+		namedSql:   base.namedSql,
+		tokens:     base.tokens,
+		opts:       base.opts,
+		paramNames: append([]string{}, base.paramNames...),
+		refAt:      append([]int{}, base.refAt...),
+		skip:       base.skip,
+		page:       &pageSpec{limit, offset},
+	}
+	paged.pieces = append(paged.pieces, base.pieces...)
+	paged.pageAt = len(paged.pieces)
+	paging := LimitOffset
+	if d := base.opts.Dialect; d != nil {
+		paging = d.Paging
+	}
+	switch {
+	case limit < 1 || offset < 0:
+		paged.err = fmt.Errorf("invalid page limit %d offset %d", limit, offset)
+	case paging == OffsetFetch:
+		paged.pieces = append(paged.pieces,
+			piece{text: " OFFSET "}, piece{arg: &arg{name: "offset", value: offset}},
+			piece{text: " ROWS FETCH NEXT "}, piece{arg: &arg{name: "limit", value: limit}},
+			piece{text: " ROWS ONLY"},
+		)
+	case paging == LimitOnly && offset > 0:
+		paged.err = fmt.Errorf("dialect %s does not support offset", base.opts.Dialect.Name)
+	case paging == LimitOnly:
+		paged.pieces = append(paged.pieces, piece{text: " LIMIT "}, piece{arg: &arg{name: "limit", value: limit}})
+	default:
+		paged.pieces = append(paged.pieces,
+			piece{text: " LIMIT "}, piece{arg: &arg{name: "limit", value: limit}},
+			piece{text: " OFFSET "}, piece{arg: &arg{name: "offset", value: offset}},
+		)
+	}
+	for i := paged.pageAt; i < len(paged.pieces); i++ {
+		if pc := paged.pieces[i]; pc.arg != nil {
+			paged.paramNames = append(paged.paramNames, pc.arg.name)
+			paged.refAt = append(paged.refAt, i)
+		}
+	}
+	paged.render()
+	return paged
+}
+
+// Count returns query counting rows of q, wrapped as
+// `SELECT COUNT(*) AS total FROM (...) t` without its top level ORDER BY,
+// LIMIT, OFFSET and FETCH clauses. It binds the parameters of q referenced
+// before those clauses. Binding the result again with With counts the newly
+// bound query
+func Count(q QueryMapper) QueryMapper {
+	base, err := fragmentPieces(q)
+	if err != nil {
+		return &query{opts: NewOptions(), paramNames: make([]string, 0), err: err}
+	}
+	counted := &query{
+		namedSql:   base.namedSql,
+		tokens:     base.tokens,
+		opts:       base.opts,
+		paramNames: make([]string, 0),
+		skip:       base.skip,
+		counted:    true,
+	}
+	if d := base.opts.Dialect; d != nil && d.Paging == LimitOnly {
+		counted.err = fmt.Errorf("dialect %s does not support counting subquery", d.Name)
+		return counted
+	}
+	pieces := base.pieces
+	if base.pageAt > 0 {
+		pieces = pieces[:base.pageAt]
+	}
+	counted.pieces = append(counted.pieces, piece{text: "SELECT COUNT(*) AS total FROM ("})
+	// kept is count of pieces kept whole
+	kept := len(pieces)
+	if idx, offset, ok := trailingClause(pieces, base.opts); ok {
+		kept = idx
+		counted.pieces = append(counted.pieces, pieces[:idx]...)
+		counted.pieces = append(counted.pieces, piece{text: strings.TrimRight(pieces[idx].text[:offset], " \t\r\n")})
+	} else {
+		counted.pieces = append(counted.pieces, pieces...)
+	}
+	counted.pieces = append(counted.pieces, piece{text: ") t"})
+	// Keep names of parameters referenced before the stripped clauses
+	for i, at := range base.refAt {
+		if at < kept && i < len(base.paramNames) {
+			counted.paramNames = append(counted.paramNames, base.paramNames[i])
+			counted.refAt = append(counted.refAt, at+1)
+		}
+	}
+	counted.render()
+	return counted
+}
+
+var trailingKeywords = []string{"ORDER BY", "LIMIT", "OFFSET", "FETCH"}
+
+// trailingClause returns index of the text piece and offset in it where the
+// first top level ORDER BY, LIMIT, OFFSET or FETCH clause starts. String
+// literals and comments are skipped according to opts dialect
+func trailingClause(pieces []piece, opts *Options) (int, int, bool) {
+	escapes, hashComments := opts.backslashEscapes(), opts.hashComments()
+	depth := 0
+	for idx, pc := range pieces {
+		if pc.arg != nil || pc.ident != "" {
+			continue
+		}
+		s := pc.text
+		for i := 0; i < len(s); {
+			c := s[i]
+			switch {
+			case c == '\'' || c == '"' || c == '`':
+				end, err := skipQuoted(s, i, escapes && c != '`')
+				if err != nil {
+					return 0, 0, false
+				}
+				i = end
+				continue
+			case c == '$':
+				end, err := skipDollarQuoted(s, i)
+				if err != nil {
+					return 0, 0, false
+				}
+				i = end
+				continue
+			case (c == '-' && strings.HasPrefix(s[i:], "--")) || (c == '#' && hashComments):
+				end := strings.IndexByte(s[i:], '\n')
+				if end < 0 {
+					i = len(s)
+				} else {
+					i += end
+				}
+				continue
+			case c == '/' && strings.HasPrefix(s[i:], "/*"):
+				end := strings.Index(s[i+2:], "*/")
+				if end < 0 {
+					return 0, 0, false
+				}
+				i += end + 4
+				continue
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			case depth == 0 && (i == 0 || !isParamChar(s[i-1])):
+				for _, keyword := range trailingKeywords {
+					if hasKeyword(s[i:], keyword) && isClause(keyword, s[i+len(keyword):], pieces[idx+1:]) {
+						return idx, i, true
+					}
+				}
+			}
+			i++
+		}
+	}
+	return 0, 0, false
+}
+
+// isClause reports whether keyword followed by rest of its text piece and
+// next pieces starts a clause. LIMIT, OFFSET and FETCH are not reserved in
+// every database, they must be followed by their argument not to be taken
+// for a column of the same name
+func isClause(keyword, rest string, next []piece) bool {
+	rest = strings.TrimLeft(rest, " \t\r\n")
+	switch {
+	case keyword == "ORDER BY":
+		return true
+	case rest == "":
+		return len(next) > 0 && next[0].arg != nil
+	case keyword == "FETCH":
+		return hasKeyword(rest, "FIRST") || hasKeyword(rest, "NEXT")
+	}
+	return (rest[0] >= '0' && rest[0] <= '9') || hasKeyword(rest, "ALL")
+}
+
+// hasKeyword reports whether s starts with keyword, case insensitive and
+// followed by a word boundary. Spaces in keyword match any whitespace
+func hasKeyword(s, keyword string) bool {
+	for _, word := range strings.Split(keyword, " ") {
+		if len(s) < len(word) || !strings.EqualFold(s[:len(word)], word) {
+			return false
+		}
+		s = s[len(word):]
+		if len(s) > 0 && isParamChar(s[0]) {
+			return false
+		}
+		s = strings.TrimLeft(s, " \t\r\n")
+	}
+	return true
+}
+
+// Keyset paginates by values of the ordering columns of the last row of the
+// previous page
+type Keyset struct {
+	columns []string
+	desc    bool
+	after   []interface{}
+	last    []interface{}
+	err     error
+}
+
+// NewKeyset returns keyset pagination over ordering columns, starting after
+// the row encoded in cursor, or from the first row when cursor is empty
+func NewKeyset(cursor string, columns ...string) *Keyset {
+	k := &Keyset{columns: columns}
+	if cursor == "" {
+		return k
+	}
+	k.after, k.err = decodeCursor(cursor)
+	if k.err == nil && len(k.after) != len(columns) {
+		k.err = fmt.Errorf("cursor has %d values, expected %d", len(k.after), len(columns))
+	}
+	return k
+}
+
+// Descending paginates rows ordered descending by the keyset columns
+func (k *Keyset) Descending() *Keyset {
+	k.desc = true
+	return k
+}
+
+// Predicate returns fragment selecting rows after the cursor, e.g.
+// `(a, b) > (?, ?)`, to be embedded with Embed. It is always true on the
+// first page
+func (k *Keyset) Predicate() QueryMapper {
+	if k.err != nil {
+		return &query{opts: NewOptions(), paramNames: make([]string, 0), err: k.err}
+	}
+	if k.after == nil {
+		return Fragment("1 = 1")
+	}
+	pred := &query{opts: NewOptions(), paramNames: make([]string, 0)}
+	pred.pieces = append(pred.pieces, piece{text: "("})
+	for i, column := range k.columns {
+		if i > 0 {
+			pred.pieces = append(pred.pieces, piece{text: ", "})
+		}
+		pred.pieces = append(pred.pieces, piece{ident: column})
+	}
+	op := ") > ("
+	if k.desc {
+		op = ") < ("
+	}
+	pred.pieces = append(pred.pieces, piece{text: op})
+	for i, column := range k.columns {
+		if i > 0 {
+			pred.pieces = append(pred.pieces, piece{text: ", "})
+		}
+		pred.pieces = append(pred.pieces, piece{arg: &arg{name: column, value: k.after[i]}})
+	}
+	pred.pieces = append(pred.pieces, piece{text: ")"})
+	pred.render()
+	pred.namedSql = pred.sql
+	return pred
+}
+
+// Map returns rowMapper recording keyset column values of every mapped row,
+// the last one is used by Cursor
+func (k *Keyset) Map(rowMapper RowMapper) RowMapper {
+	return func() *MappedColumns {
+		mapped := rowMapper()
+		targets := make([]*interface{}, len(k.columns))
+		for i, column := range k.columns {
+			for _, c := range mapped.Columns {
+				if c.Name() == column || strings.HasSuffix(column, "."+c.Name()) {
+					targets[i] = c.Target()
+				}
+			}
+		}
+		cb := mapped.cb
+		return mapped.Then(func() error {
+			if err := cb(); err != nil {
+				return err
+			}
+			last := make([]interface{}, len(targets))
+			for i, target := range targets {
+				if target == nil {
+					return fmt.Errorf("keyset column %s is not mapped", k.columns[i])
+				}
+				last[i] = reflect.ValueOf(*target).Elem().Interface()
+			}
+			k.last = last
+			return nil
+		})
+	}
+}
+
+// Cursor returns opaque token of the last mapped row, to be passed to
+// NewKeyset for the next page. It is empty when no row was mapped
+func (k *Keyset) Cursor() (string, error) {
+	if k.last == nil {
+		return "", nil
+	}
+	values := make([]interface{}, len(k.last))
+	for i, v := range k.last {
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		values[i] = v
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	values := make([]interface{}, 0)
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	for i, v := range values {
+		if n, ok := v.(json.Number); ok {
+			if integer, err := n.Int64(); err == nil {
+				values[i] = integer
+			} else if float, err := n.Float64(); err == nil {
+				values[i] = float
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,172 @@
+package dbmapper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	namedSql := "SELECT id FROM users WHERE tenant_id = :tenant ORDER BY id"
+	cases := []struct {
+		dialect     *Dialect
+		offset      int
+		expectedSql string
+	}{
+		{MySQL, 40, "SELECT id FROM users WHERE tenant_id = ? ORDER BY id LIMIT ? OFFSET ?"},
+		{PostgreSQL, 40, "SELECT id FROM users WHERE tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3"},
+		{SQLServer, 40, "SELECT id FROM users WHERE tenant_id = @p1 ORDER BY id OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY"},
+		{Cassandra, 0, "SELECT id FROM users WHERE tenant_id = ? ORDER BY id LIMIT ?"},
+	}
+	for _, c := range cases {
+		q := Page(Prepare(namedSql, UseDialect(c.dialect)).With(Param("tenant", 1)), 20, c.offset)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error, got %v instead", q.Error())
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+	}
+	q := Page(Prepare(namedSql, UseDialect(SQLServer)).With(Param("tenant", 1)), 20, 40)
+	expectedParams := []interface{}{1, 40, 20}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	expectedNames := []string{"tenant", "offset", "limit"}
+	if !reflect.DeepEqual(q.ParamNames(), expectedNames) {
+		t.Errorf("Fail: expect %v parameter names, got %v instead", expectedNames, q.ParamNames())
+	}
+	q = Page(Prepare(namedSql, UseDialect(SQLServer), NamedArgs()).With(Param("tenant", 1)), 20, 40)
+	if !reflect.DeepEqual(q.ParamNames(), expectedNames) {
+		t.Errorf("Fail: expect %v named parameter names, got %v instead", expectedNames, q.ParamNames())
+	}
+	q = Page(Prepare(namedSql, UseDialect(MySQL)).With(Param("tenant", 1)), 20, 40).With(Param("tenant", 2))
+	expectedSql := "SELECT id FROM users WHERE tenant_id = ? ORDER BY id LIMIT ? OFFSET ?"
+	if q.SQL() != expectedSql || !reflect.DeepEqual(q.Params(), []interface{}{2, 20, 40}) {
+		t.Errorf("Fail: expect paging kept when bound again, got [ %v ] %v instead", q.SQL(), q.Params())
+	}
+	if q := Page(Prepare(namedSql, UseDialect(Cassandra)).With(Param("tenant", 1)), 20, 40); q.Error() == nil {
+		t.Errorf("Fail: expect offset error for cassandra, got [ %v ] instead", q.SQL())
+	}
+	if q := Page(Prepare(namedSql).With(), 20, 0); q.Error() == nil {
+		t.Errorf("Fail: expect missing parameter error, got [ %v ] instead", q.SQL())
+	}
+}
+
+type keysetRow struct {
+	ID      int64
+	Created string
+	Name    string
+}
+
+func keysetMapper(result *[]keysetRow) RowMapper {
+	return func() *MappedColumns {
+		row := keysetRow{}
+		return Columns(
+			Column("id").As(&row.ID),
+			Column("created").As(&row.Created),
+			Column("name").As(&row.Name),
+		).Then(func() error {
+			*result = append(*result, row)
+			return nil
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	listUsers := Prepare("SELECT id, created, name FROM users WHERE tenant_id = :tenant AND :after ORDER BY created, id", UseDialect(PostgreSQL))
+	keyset := NewKeyset("", "created", "u.id")
+	q := Page(listUsers.With(Param("tenant", 1), Embed("after", keyset.Predicate())), 2, 0)
+	expectedSql := "SELECT id, created, name FROM users WHERE tenant_id = $1 AND 1 = 1 ORDER BY created, id LIMIT $2 OFFSET $3"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	result := make([]keysetRow, 0)
+	rowMapper := keyset.Map(keysetMapper(&result))
+	for _, r := range []keysetRow{{1, "2020-01-01", "alice"}, {2, "2020-01-02", "bob"}} {
+		mapped := rowMapper()
+		*(*mapped.Columns[0].Target()).(*int64) = r.ID
+		*(*mapped.Columns[1].Target()).(*string) = r.Created
+		*(*mapped.Columns[2].Target()).(*string) = r.Name
+		if err := mapped.Done(); err != nil {
+			t.Fatalf("Fail: expect no error, got %v instead", err)
+		}
+	}
+	if len(result) != 2 {
+		t.Errorf("Fail: expect 2 rows, got %v instead", result)
+	}
+	cursor, err := keyset.Cursor()
+	if err != nil || cursor == "" {
+		t.Fatalf("Fail: expect cursor, got %v instead", err)
+	}
+	keyset = NewKeyset(cursor, "created", "u.id")
+	q = Page(listUsers.With(Param("tenant", 1), Embed("after", keyset.Predicate())), 2, 0)
+	expectedSql = `SELECT id, created, name FROM users WHERE tenant_id = $1 AND ("created", "u"."id") > ($2, $3) ORDER BY created, id LIMIT $4 OFFSET $5`
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedParams := []interface{}{1, "2020-01-02", int64(2), 2, 0}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	if k := NewKeyset("not a cursor", "created", "id"); k.Predicate().Error() == nil {
+		t.Errorf("Fail: expect invalid cursor error")
+	}
+	if k := NewKeyset(cursor, "id"); k.Predicate().Error() == nil {
+		t.Errorf("Fail: expect cursor arity error")
+	}
+	if cursor, _ := NewKeyset("", "id").Cursor(); cursor != "" {
+		t.Errorf("Fail: expect empty cursor before mapping, got %v instead", cursor)
+	}
+}
+
+func TestCount(t *testing.T) {
+	namedSql := "SELECT id, (SELECT max(x) FROM y ORDER BY x LIMIT 1) m, row_number() OVER (ORDER BY id) FROM users " +
+		"WHERE name = 'order by' /* limit */ AND tenant_id = :tenant ORDER BY :sort LIMIT 5"
+	q := Page(Prepare(namedSql, UseDialect(PostgreSQL)).With(Param("tenant", 1), Ident("sort", "id")), 10, 20)
+	count := Count(q)
+	if count.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", count.Error())
+	}
+	expectedSql := "SELECT COUNT(*) AS total FROM (SELECT id, (SELECT max(x) FROM y ORDER BY x LIMIT 1) m, row_number() OVER (ORDER BY id) FROM users " +
+		"WHERE name = 'order by' /* limit */ AND tenant_id = $1) t"
+	if count.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, count.SQL())
+	}
+	if len(count.Params()) != 1 || count.Params()[0] != 1 {
+		t.Errorf("Fail: expect [1] parameters, got %v instead", count.Params())
+	}
+	if !reflect.DeepEqual(count.ParamNames(), []string{"tenant"}) {
+		t.Errorf("Fail: expect [tenant] parameter names, got %v instead", count.ParamNames())
+	}
+	count = count.With(Param("tenant", 2), Ident("sort", "name"))
+	if count.SQL() != expectedSql || !reflect.DeepEqual(count.Params(), []interface{}{2}) {
+		t.Errorf("Fail: expect [ %v ] sql string with [2] bound again, got [ %v ] %v instead", expectedSql, count.SQL(), count.Params())
+	}
+	count = Count(Prepare("SELECT id, offset, fetch FROM t WHERE limit = :a AND offset > 0 ORDER BY id LIMIT :n", UseDialect(MySQL)).With(
+		Param("a", 1), Param("n", 10),
+	))
+	expectedSql = "SELECT COUNT(*) AS total FROM (SELECT id, offset, fetch FROM t WHERE limit = ? AND offset > 0) t"
+	if count.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, count.SQL())
+	}
+	if !reflect.DeepEqual(count.ParamNames(), []string{"a"}) || !reflect.DeepEqual(count.Params(), []interface{}{1}) {
+		t.Errorf("Fail: expect parameter a only, got %v %v instead", count.ParamNames(), count.Params())
+	}
+	count = Count(Prepare("SELECT id FROM t WHERE a = :a LIMIT :n OFFSET 5", UseDialect(MySQL)).With(Param("a", 1), Param("n", 10)))
+	expectedSql = "SELECT COUNT(*) AS total FROM (SELECT id FROM t WHERE a = ?) t"
+	if count.SQL() != expectedSql || !reflect.DeepEqual(count.ParamNames(), []string{"a"}) {
+		t.Errorf("Fail: expect [ %v ] sql string with [a], got [ %v ] %v instead", expectedSql, count.SQL(), count.ParamNames())
+	}
+	count = Count(Prepare("SELECT id FROM users WHERE tenant_id = :tenant", UseDialect(MySQL)).With(Param("tenant", 1)))
+	expectedSql = "SELECT COUNT(*) AS total FROM (SELECT id FROM users WHERE tenant_id = ?) t"
+	if count.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, count.SQL())
+	}
+	if count := Count(Prepare("SELECT id FROM users", UseDialect(Cassandra))); count.Error() == nil {
+		t.Errorf("Fail: expect unsupported count error for cassandra")
+	}
+}
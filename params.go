@@ -0,0 +1,381 @@
+package dbmapper
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// paramValues returns bound values of v. Slices other than []byte and
+// driver.Valuer implementations are expanded into multiple values
+func paramValues(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{v}
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return []interface{}{v}
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// ParamsFromStruct returns parameters from fields of struct (or pointer to
+// struct) v. Fields are named by their `db` tag or snake cased field name
+func ParamsFromStruct(v interface{}) ([]Parameter, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot bind parameters from nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot bind parameters from %T, expected a struct", v)
+	}
+	fields := cachedFields(rv.Type())
+	params := make([]Parameter, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		params = append(params, &parameter{f.name, paramValues(fv.Interface())})
+	}
+	return params, nil
+}
+
+// ParamsFromMap returns parameters from map entries
+func ParamsFromMap(m map[string]interface{}) []Parameter {
+	params := make([]Parameter, 0, len(m))
+	for name, v := range m {
+		params = append(params, &parameter{name, paramValues(v)})
+	}
+	return params
+}
+
+// arg is a value bound to a placeholder
+type arg struct {
+	name   string
+	value  interface{}
+	secret bool
+	// like is the LIKE pattern built from string value
+	like likeKind
+}
+
+// piece is a part of bound query, either SQL text, an identifier quoted
+// when rendered, or a bound value
+type piece struct {
+	text  string
+	ident string
+	arg   *arg
+}
+
+// argList returns pieces rendering values as comma separated placeholders
+func argList(name string, values []interface{}) []piece {
+	pieces := make([]piece, 0, len(values)*2)
+	for idx, v := range values {
+		if idx > 0 {
+			pieces = append(pieces, piece{text: ", "})
+		}
+		pieces = append(pieces, piece{arg: &arg{name: name, value: v}})
+	}
+	return pieces
+}
+
+// expander is implemented by parameters which are not rendered as comma
+// separated placeholder list. o is the options of the query binding it
+type expander interface {
+	Parameter
+	expand(o *Options) ([]piece, error)
+}
+
+// rowsParameter binds groups of values, rendered as `(?, ?), (?, ?)`, or
+// wrapped in parentheses as `((?, ?), (?, ?))`
+type rowsParameter struct {
+	name string
+	rows [][]interface{}
+	wrap bool
+}
+
+func (p *rowsParameter) Name() string {
+	return p.name
+}
+
+func (p *rowsParameter) Value() ([]interface{}, error) {
+	values := make([]interface{}, 0)
+	for _, row := range p.rows {
+		values = append(values, row...)
+	}
+	return values, nil
+}
+
+func (p *rowsParameter) expand(o *Options) ([]piece, error) {
+	if len(p.rows) == 0 {
+		return nil, nil
+	}
+	pieces := make([]piece, 0)
+	if p.wrap {
+		pieces = append(pieces, piece{text: "("})
+	}
+	group := "row"
+	if p.wrap {
+		group = "tuple"
+	}
+	for idx, row := range p.rows {
+		if len(row) != len(p.rows[0]) {
+			return nil, fmt.Errorf("%s %d has %d values, expected %d", group, idx+1, len(row), len(p.rows[0]))
+		}
+		if idx > 0 {
+			pieces = append(pieces, piece{text: ", "})
+		}
+		pieces = append(pieces, piece{text: "("})
+		pieces = append(pieces, argList(p.name, row)...)
+		pieces = append(pieces, piece{text: ")"})
+	}
+	if p.wrap {
+		pieces = append(pieces, piece{text: ")"})
+	}
+	return pieces, nil
+}
+
+// groupValues converts slice of structs, slices or arrays into groups of
+// values
+func groupValues(v interface{}) ([][]interface{}, error) {
+	if rows, ok := v.([][]interface{}); ok {
+		return rows, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot bind rows from %T, expected a slice", v)
+	}
+	rows := make([][]interface{}, rv.Len())
+	for i := range rows {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Struct:
+			for _, f := range cachedFields(elem.Type()) {
+				if fv, ok := fieldByIndex(elem, f.index); ok {
+					rows[i] = append(rows[i], fv.Interface())
+				} else {
+					rows[i] = append(rows[i], nil)
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			rows[i] = make([]interface{}, elem.Len())
+			for j := range rows[i] {
+				rows[i][j] = elem.Index(j).Interface()
+			}
+		default:
+			return nil, fmt.Errorf("cannot bind row %d from %s, expected a struct or slice", i+1, elem.Kind())
+		}
+	}
+	return rows, nil
+}
+
+// Rows returns parameter for multi-row insert, `VALUES :rows` is expanded to
+// `VALUES (?, ?), (?, ?)`. rows is either [][]interface{}, slice of slices,
+// or slice of structs whose fields are bound in declaration order.
+// Use QueryMapper.Batches to execute queries exceeding dialect placeholder
+// limit
+func Rows(name string, rows interface{}) Parameter {
+	groups, err := groupValues(rows)
+	if err != nil {
+		return &errParameter{name, err}
+	}
+	return &rowsParameter{name, groups, false}
+}
+
+// Tuples returns parameter for composite key IN predicate,
+// `(a, b) IN :keys` is expanded to `(a, b) IN ((?, ?), (?, ?))`. tuples is
+// either [][]interface{}, slice of slices, or slice of structs. Every tuple
+// must have the same number of values
+func Tuples(name string, tuples interface{}) Parameter {
+	groups, err := groupValues(tuples)
+	if err != nil {
+		return &errParameter{name, err}
+	}
+	return &rowsParameter{name, groups, true}
+}
+
+// errParameter is a parameter which could not be constructed
+type errParameter struct {
+	name string
+	err  error
+}
+
+func (p *errParameter) Name() string {
+	return p.name
+}
+
+func (p *errParameter) Value() ([]interface{}, error) {
+	return nil, p.err
+}
+
+// EmptyPolicy controls how a parameter without values is bound
+type EmptyPolicy int
+
+const (
+	// EmptyError fails binding with EmptyParamError
+	EmptyError EmptyPolicy = iota
+	// EmptyNull renders the parameter as NULL, turning `IN (:ids)` into the
+	// never true `IN (NULL)` predicate
+	EmptyNull
+	// EmptySkip renders the parameter as EmptyNull does and marks the query
+	// as skipped, see QueryMapper.Skip
+	EmptySkip
+)
+
+// emptyParameter is a parameter bound with a non default empty policy
+type emptyParameter struct {
+	Parameter
+	policy EmptyPolicy
+}
+
+// OnEmpty returns p bound according to policy when it has no values
+func OnEmpty(p Parameter, policy EmptyPolicy) Parameter {
+	return &emptyParameter{p, policy}
+}
+
+// secretParameter is a parameter whose values are redacted from
+// QueryMapper.Interpolated
+type secretParameter struct {
+	Parameter
+}
+
+// Secret returns parameter like Param whose values are rendered as *** by
+// QueryMapper.Interpolated
+func Secret(name string, val ...interface{}) Parameter {
+	return &secretParameter{Param(name, val...)}
+}
+
+var identPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)*$`)
+
+// identParameter renders a quoted identifier
+type identParameter struct {
+	name    string
+	value   string
+	allowed []string
+}
+
+func (p *identParameter) Name() string {
+	return p.name
+}
+
+func (p *identParameter) Value() ([]interface{}, error) {
+	if len(p.allowed) == 0 {
+		if !identPattern.MatchString(p.value) {
+			return nil, fmt.Errorf("invalid identifier %q", p.value)
+		}
+		return []interface{}{p.value}, nil
+	}
+	for _, allowed := range p.allowed {
+		if p.value == allowed {
+			return []interface{}{p.value}, nil
+		}
+	}
+	return nil, fmt.Errorf("identifier %q is not allowed", p.value)
+}
+
+func (p *identParameter) expand(o *Options) ([]piece, error) {
+	if _, err := p.Value(); err != nil {
+		return nil, err
+	}
+	return []piece{{ident: p.value}}, nil
+}
+
+// Ident returns parameter rendering value as identifier (table or column
+// name) quoted for the query dialect. value must be one of allowed, or a
+// plain, optionally dot qualified, identifier when allowed is empty
+func Ident(name, value string, allowed ...string) Parameter {
+	return &identParameter{name, value, allowed}
+}
+
+// directionParameter renders sort direction
+type directionParameter struct {
+	name  string
+	value string
+}
+
+func (p *directionParameter) Name() string {
+	return p.name
+}
+
+func (p *directionParameter) Value() ([]interface{}, error) {
+	switch strings.ToUpper(p.value) {
+	case "", "ASC":
+		return []interface{}{"ASC"}, nil
+	case "DESC":
+		return []interface{}{"DESC"}, nil
+	}
+	return nil, fmt.Errorf("invalid sort direction %q", p.value)
+}
+
+func (p *directionParameter) expand(o *Options) ([]piece, error) {
+	value, err := p.Value()
+	if err != nil {
+		return nil, err
+	}
+	return []piece{{text: value[0].(string)}}, nil
+}
+
+// Direction returns parameter rendering sort direction, ASC or DESC. Empty
+// value is rendered as ASC
+func Direction(name, value string) Parameter {
+	return &directionParameter{name, value}
+}
+
+// likeKind is the LIKE pattern built from a likeParameter value
+type likeKind int
+
+const (
+	likeNone likeKind = iota
+	likeContains
+	likeStartsWith
+	likeEndsWith
+)
+
+// likeParameter binds LIKE pattern with escaped wildcards
+type likeParameter struct {
+	name  string
+	value string
+	kind  likeKind
+}
+
+func (p *likeParameter) Name() string {
+	return p.name
+}
+
+func (p *likeParameter) Value() ([]interface{}, error) {
+	pattern, _ := ansi.likePattern(p.kind, p.value)
+	return []interface{}{pattern}, nil
+}
+
+func (p *likeParameter) expand(o *Options) ([]piece, error) {
+	return []piece{{arg: &arg{name: p.name, value: p.value, like: p.kind}}}, nil
+}
+
+// Contains returns parameter matching value anywhere with LIKE. Wildcards in
+// value are escaped for the query dialect, and the placeholder is followed
+// by the matching ESCAPE clause
+func Contains(name, value string) Parameter {
+	return &likeParameter{name, value, likeContains}
+}
+
+// StartsWith returns parameter matching value prefix with LIKE, see Contains
+func StartsWith(name, value string) Parameter {
+	return &likeParameter{name, value, likeStartsWith}
+}
+
+// EndsWith returns parameter matching value suffix with LIKE, see Contains
+func EndsWith(name, value string) Parameter {
+	return &likeParameter{name, value, likeEndsWith}
+}
@@ -0,0 +1,362 @@
+package dbmapper
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type paramsBase struct {
+	TenantID int64 `db:"tenant"`
+}
+
+type paramsUser struct {
+	paramsBase
+	ID          string
+	Name        string `db:"user_name"`
+	PhoneNumber string
+	Roles       []string
+	Password    string `db:"-"`
+	secret      string
+}
+
+func TestParamsFromStruct(t *testing.T) {
+	user := paramsUser{
+		paramsBase:  paramsBase{TenantID: 7},
+		ID:          "123",
+		Name:        "alice",
+		PhoneNumber: "0827126",
+		Roles:       []string{"admin", "user"},
+	}
+	params, err := ParamsFromStruct(&user)
+	if err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	namedSql := "insert into test(tenant, id, name, phone, role) values (:tenant, :id, :user_name, :phone_number, :roles)"
+	q := Prepare(namedSql).With(params...)
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	expectedParams := []interface{}{int64(7), "123", "alice", "0827126", "admin", "user"}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	q = Prepare("select id from test where password = :password and secret = :secret").With(params...)
+	if q.Error() == nil || !strings.Contains(q.Error().Error(), ":password") || !strings.Contains(q.Error().Error(), ":secret") {
+		t.Errorf("Fail: expect error listing missing parameters, got %v instead", q.Error())
+	}
+	if _, err := ParamsFromStruct("abc"); err == nil {
+		t.Errorf("Fail: expect error binding parameters from non struct value")
+	}
+}
+
+type paramsTags []string
+
+func (t paramsTags) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+func TestParamsFromMap(t *testing.T) {
+	q := Prepare("select id from test where id IN (:ids) and name = :name").With(ParamsFromMap(map[string]interface{}{
+		"ids":  []int{1, 2},
+		"name": "alice",
+		"blob": []byte("abc"),
+	})...)
+	expectedParams := []interface{}{1, 2, "alice"}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	q = Prepare("select id from test where tags = :tags").With(ParamsFromMap(map[string]interface{}{
+		"tags": paramsTags{"a", "b"},
+	})...)
+	if len(q.Params()) != 1 {
+		t.Fatalf("Fail: expect driver.Valuer slice bound as a single parameter, got %v instead", q.Params())
+	}
+	if q.Params()[0] != "a,b" {
+		t.Errorf("Fail: expect a,b parameter, got %v instead", q.Params()[0])
+	}
+}
+
+type rowsUser struct {
+	ID   int
+	Name string
+}
+
+func TestRowsParameter(t *testing.T) {
+	q := Prepare("insert into test(id, name) values :rows").With(
+		Rows("rows", [][]interface{}{{1, "alice"}, {2, "bob"}}),
+	)
+	expectedSql := "insert into test(id, name) values (?, ?), (?, ?)"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	q = Prepare("insert into test(id, name) values :rows", UseDialect(PostgreSQL)).With(
+		Rows("rows", []rowsUser{{1, "alice"}, {2, "bob"}}),
+	)
+	expectedSql = "insert into test(id, name) values ($1, $2), ($3, $4)"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedParams := []interface{}{1, "alice", 2, "bob"}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	if len(q.Batches()) != 1 || q.Batches()[0] != q {
+		t.Errorf("Fail: expect a single batch, got %v instead", q.Batches())
+	}
+	q = Prepare("insert into test(id, name) values :rows").With(
+		Rows("rows", [][]interface{}{{1, "alice"}, {2}}),
+	)
+	if q.Error() == nil || !strings.Contains(q.Error().Error(), "row 2 has 1 values, expected 2") {
+		t.Errorf("Fail: expect row arity error, got %v instead", q.Error())
+	}
+	if q = Prepare("insert into test(id) values :rows").With(Rows("rows", 1)); q.Error() == nil {
+		t.Errorf("Fail: expect error binding rows from non slice value")
+	}
+}
+
+func TestRowsBatches(t *testing.T) {
+	dialect := &Dialect{Name: "test", Placeholder: DollarNumbered, MaxParams: 7}
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	q := Prepare("insert into test(id, name, tenant) select id, name, :tenant from (values :rows) t", UseDialect(dialect)).With(
+		Param("tenant", 9),
+		Rows("rows", rows),
+	)
+	if q.Error() != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", q.Error())
+	}
+	batches := q.Batches()
+	if len(batches) != 2 {
+		t.Fatalf("Fail: expect 2 batches, got %v instead", len(batches))
+	}
+	expectedSql := "insert into test(id, name, tenant) select id, name, $1 from (values ($2, $3), ($4, $5), ($6, $7)) t"
+	if batches[0].SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, batches[0].SQL())
+	}
+	expectedParams := []interface{}{9, 4, "d", 5, "e"}
+	if len(batches[1].Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, batches[1].Params())
+	}
+	for idx, p := range batches[1].Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, batches[1].Params())
+		}
+	}
+	q = Prepare("select id from test where id IN (:ids)", UseDialect(dialect)).With(
+		Param("ids", 1, 2, 3, 4, 5, 6, 7, 8),
+	)
+	if q.Error() == nil {
+		t.Errorf("Fail: expect placeholder limit error, got [ %v ] instead", q.SQL())
+	}
+	q = Prepare("insert into test(id, name) values :rows", UseDialect(dialect)).With(
+		OnEmpty(Rows("rows", rows), EmptyNull),
+	)
+	if q.Error() != nil || len(q.Batches()) != 2 {
+		t.Errorf("Fail: expect wrapped rows to be split in 2 batches, got %v instead", q.Error())
+	}
+	q = Prepare("insert into test values :rows; select id from test where id IN (:ids)", UseDialect(dialect)).With(
+		Rows("rows", [][]interface{}{{}, {}}),
+		Param("ids", 1, 2, 3, 4, 5, 6, 7, 8),
+	)
+	if q.Error() == nil {
+		t.Errorf("Fail: expect placeholder limit error for empty rows, got [ %v ] instead", q.SQL())
+	}
+}
+
+func TestTuplesParameter(t *testing.T) {
+	q := Prepare("select id from test where (tenant_id, id) IN :keys", Placeholder(DollarNumbered)).With(
+		Tuples("keys", [][]interface{}{{1, "a"}, {1, "b"}, {2, "a"}}),
+	)
+	expectedSql := "select id from test where (tenant_id, id) IN (($1, $2), ($3, $4), ($5, $6))"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	expectedParams := []interface{}{1, "a", 1, "b", 2, "a"}
+	if len(q.Params()) != len(expectedParams) {
+		t.Fatalf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+	}
+	for idx, p := range q.Params() {
+		if expectedParams[idx] != p {
+			t.Errorf("Fail: expect %v parameters, got %v instead", expectedParams, q.Params())
+		}
+	}
+	q = Prepare("select id from test where (tenant_id, id) IN :keys").With(
+		Tuples("keys", [][]int{{1, 2}, {1, 2, 3}}),
+	)
+	if q.Error() == nil || q.Error().Error() != "invalid value for parameter :keys: tuple 2 has 3 values, expected 2" {
+		t.Errorf("Fail: expect tuple arity error, got %v instead", q.Error())
+	}
+}
+
+func TestEmptyParameter(t *testing.T) {
+	namedSql := "select id from test where id IN (:ids) and (tenant_id, id) IN :keys"
+	q := Prepare(namedSql).With(Param("ids"), Tuples("keys", [][]interface{}{}))
+	var emptyErr *EmptyParamError
+	if !errors.As(q.Error(), &emptyErr) || emptyErr.Name != "ids" {
+		t.Errorf("Fail: expect EmptyParamError for ids, got %v instead", q.Error())
+	}
+	var missingErr *MissingParamError
+	if errors.As(q.Error(), &missingErr) {
+		t.Errorf("Fail: expect no MissingParamError, got %v instead", q.Error())
+	}
+	q = Prepare(namedSql).With(Param("ids"))
+	if !errors.As(q.Error(), &missingErr) || missingErr.Name != "keys" {
+		t.Errorf("Fail: expect MissingParamError for keys, got %v instead", q.Error())
+	}
+	q = Prepare(namedSql).With(
+		OnEmpty(Param("ids"), EmptyNull),
+		OnEmpty(Tuples("keys", [][]interface{}{}), EmptyNull),
+	)
+	expectedSql := "select id from test where id IN (NULL) and (tenant_id, id) IN (NULL)"
+	if q.Error() != nil || q.SQL() != expectedSql || len(q.Params()) != 0 {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] with error %v instead", expectedSql, q.SQL(), q.Error())
+	}
+	if q.Skip() {
+		t.Errorf("Fail: expect query not to be skipped")
+	}
+	q = Prepare(namedSql).With(
+		OnEmpty(Param("ids"), EmptySkip),
+		OnEmpty(Tuples("keys", [][]interface{}{{1, 2}}), EmptySkip),
+	)
+	if q.Error() != nil || !q.Skip() {
+		t.Errorf("Fail: expect query to be skipped, got error %v", q.Error())
+	}
+	q = Prepare(namedSql).With(OnEmpty(Param("ids", 1), EmptySkip), Tuples("keys", [][]interface{}{{1, 2}}))
+	if q.Skip() || len(q.Params()) != 3 {
+		t.Errorf("Fail: expect query with values not to be skipped, got %v instead", q.Params())
+	}
+}
+
+func TestIdentParameter(t *testing.T) {
+	namedSql := "select id from :table order by :sort :dir"
+	sortable := []string{"id", "name", "created_at"}
+	cases := []struct {
+		dialect     *Dialect
+		expectedSql string
+	}{
+		{MySQL, "select id from `tenant_1`.`users` order by `created_at` DESC"},
+		{nil, "select id from `tenant_1`.`users` order by `created_at` DESC"},
+		{PostgreSQL, `select id from "tenant_1"."users" order by "created_at" DESC`},
+		{Cassandra, `select id from "tenant_1"."users" order by "created_at" DESC`},
+	}
+	for _, c := range cases {
+		opts := make([]Option, 0)
+		if c.dialect != nil {
+			opts = append(opts, UseDialect(c.dialect))
+		}
+		q := Prepare(namedSql, opts...).With(
+			Ident("table", "tenant_1.users"),
+			Ident("sort", "created_at", sortable...),
+			Direction("dir", "desc"),
+		)
+		if q.Error() != nil {
+			t.Errorf("Fail: expect no error, got %v instead", q.Error())
+		}
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+		if len(q.Params()) != 0 {
+			t.Errorf("Fail: expect no bound parameters, got %v instead", q.Params())
+		}
+	}
+	invalid := [][]Parameter{
+		{Ident("table", "users"), Ident("sort", "password", sortable...), Direction("dir", "asc")},
+		{Ident("table", "users; drop table users"), Ident("sort", "id", sortable...), Direction("dir", "asc")},
+		{Ident("table", "users"), Ident("sort", "id", sortable...), Direction("dir", "asc; drop table users")},
+	}
+	for _, params := range invalid {
+		q := Prepare(namedSql).With(params...)
+		var valueErr *ParamValueError
+		if !errors.As(q.Error(), &valueErr) {
+			t.Errorf("Fail: expect ParamValueError, got [ %v ] instead", q.SQL())
+		}
+	}
+	q := Prepare("select :col from t", UseDialect(MySQL)).With(Ident("col", "we`ird", "we`ird"))
+	if q.SQL() != "select `we``ird` from t" {
+		t.Errorf("Fail: expect escaped identifier, got [ %v ] instead", q.SQL())
+	}
+}
+
+func TestLikeParameter(t *testing.T) {
+	namedSql := "select id from test where name like :keyword and code like :prefix and email like :domain"
+	mysql := *MySQL
+	mysql.MaxParams = 1000
+	cases := []struct {
+		dialect        *Dialect
+		expectedSql    string
+		expectedParams []interface{}
+	}{
+		{
+			MySQL,
+			`select id from test where name like ? ESCAPE '\\' and code like ? ESCAPE '\\' and email like ? ESCAPE '\\'`,
+			[]interface{}{`%50\%\_off\\%`, `A\_%`, "%@example.com"},
+		},
+		{
+			&mysql,
+			`select id from test where name like ? ESCAPE '\\' and code like ? ESCAPE '\\' and email like ? ESCAPE '\\'`,
+			[]interface{}{`%50\%\_off\\%`, `A\_%`, "%@example.com"},
+		},
+		{
+			PostgreSQL,
+			`select id from test where name like $1 ESCAPE '\' and code like $2 ESCAPE '\' and email like $3 ESCAPE '\'`,
+			[]interface{}{`%50\%\_off\\%`, `A\_%`, "%@example.com"},
+		},
+		{
+			SQLServer,
+			`select id from test where name like @p1 ESCAPE '\' and code like @p2 ESCAPE '\' and email like @p3 ESCAPE '\'`,
+			[]interface{}{`%50\%\_off\\%`, `A\_%`, "%@example.com"},
+		},
+		{
+			Cassandra,
+			`select id from test where name like ? and code like ? and email like ?`,
+			[]interface{}{`%50%_off\%`, `A_%`, "%@example.com"},
+		},
+	}
+	for _, c := range cases {
+		q := Prepare(namedSql, UseDialect(c.dialect)).With(
+			Contains("keyword", `50%_off\`),
+			StartsWith("prefix", "A_"),
+			EndsWith("domain", "@example.com"),
+		)
+		if q.SQL() != c.expectedSql {
+			t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", c.expectedSql, q.SQL())
+		}
+		for idx, p := range q.Params() {
+			if c.expectedParams[idx] != p {
+				t.Errorf("Fail: expect %v parameters, got %v instead", c.expectedParams, q.Params())
+			}
+		}
+	}
+	q := Prepare("select id from test where name like :keyword", UseDialect(SQLServer), NamedArgs()).With(Contains("keyword", "[a]"))
+	if q.SQL() != `select id from test where name like @keyword ESCAPE '\'` {
+		t.Errorf("Fail: expect named LIKE parameter, got [ %v ] instead", q.SQL())
+	}
+	if q.Params()[0].(sql.NamedArg).Value != `%\[a]%` {
+		t.Errorf("Fail: expect escaped bracket, got %v instead", q.Params())
+	}
+	expectedInterpolated := `select id from test where name like N'%\[a]%' ESCAPE '\'`
+	if q.Interpolated(nil) != expectedInterpolated {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedInterpolated, q.Interpolated(nil))
+	}
+	q = Prepare("select id from test where name like :keyword").With(Contains("keyword", "a_b"))
+	if q.SQL() != `select id from test where name like ? ESCAPE '\\'` || q.Params()[0] != `%a\_b%` {
+		t.Errorf("Fail: expect MySQL escaping without dialect, got [ %v ] %v instead", q.SQL(), q.Params())
+	}
+}
@@ -0,0 +1,124 @@
+package dbmapper
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var queryNamePattern = regexp.MustCompile(`^--\s*name:\s*([a-zA-Z0-9_.-]+)\s*$`)
+
+// Registry holds named queries compiled at startup. Queries must be added
+// before the registry is shared between goroutines
+type Registry struct {
+	queries map[string]QueryMapper
+	opts    []Option
+}
+
+// NewRegistry returns an empty registry whose queries are prepared with opts
+func NewRegistry(opts ...Option) *Registry {
+	return &Registry{make(map[string]QueryMapper), opts}
+}
+
+// LoadRegistry returns registry of queries from files of fsys matching
+// pattern, e.g. an embed.FS with "queries/*.sql". Each query starts with a
+// `-- name: find_user_by_id` header line
+func LoadRegistry(fsys fs.FS, pattern string, opts ...Option) (*Registry, error) {
+	r := NewRegistry(opts...)
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Parse(file, string(content)); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Parse adds queries of a .sql file content to the registry. file is only
+// used in error messages
+func (r *Registry) Parse(file, content string) error {
+	var name string
+	var line, start int
+	var body strings.Builder
+	add := func() error {
+		if name == "" {
+			return nil
+		}
+		return r.add(fmt.Sprintf("%s:%d", file, start), name, body.String())
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if match := queryNamePattern.FindStringSubmatch(strings.TrimSpace(text)); match != nil {
+			if err := add(); err != nil {
+				return err
+			}
+			name, start = match[1], line
+			body.Reset()
+			continue
+		}
+		if name == "" {
+			if trimmed := strings.TrimSpace(text); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return fmt.Errorf("%s:%d: query without -- name: header", file, line)
+			}
+			continue
+		}
+		body.WriteString(text)
+		body.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return add()
+}
+
+// Add compiles and registers a named query
+func (r *Registry) Add(name, namedSql string) error {
+	return r.add(name, name, namedSql)
+}
+
+func (r *Registry) add(source, name, namedSql string) error {
+	if _, ok := r.queries[name]; ok {
+		return fmt.Errorf("%s: duplicate query %s", source, name)
+	}
+	namedSql = strings.TrimSuffix(strings.TrimSpace(namedSql), ";")
+	if namedSql == "" {
+		return fmt.Errorf("%s: query %s is empty", source, name)
+	}
+	q := Prepare(namedSql, r.opts...)
+	if q.Error() != nil {
+		return fmt.Errorf("%s: query %s: %w", source, name, q.Error())
+	}
+	r.queries[name] = q
+	return nil
+}
+
+// Get returns prepared query by name. When there is no such query, the
+// returned QueryMapper reports it from Error
+func (r *Registry) Get(name string) QueryMapper {
+	if q, ok := r.queries[name]; ok {
+		return q
+	}
+	return &query{opts: NewOptions(r.opts...), paramNames: make([]string, 0), err: fmt.Errorf("unknown query %s", name)}
+}
+
+// Names returns sorted names of registered queries
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.queries))
+	for name := range r.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
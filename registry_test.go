@@ -0,0 +1,61 @@
+package dbmapper
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	fsys := fstest.MapFS{
+		"queries/users.sql": &fstest.MapFile{Data: []byte(`-- Queries of users table
+
+-- name: find_user_by_id
+SELECT id, name
+FROM users
+WHERE id = :id;
+
+-- name: find_users_by_ids
+-- Users with the given ids
+SELECT id, name FROM users WHERE id IN (:ids)
+`)},
+		"queries/roles.sql": &fstest.MapFile{Data: []byte("-- name: find_roles\nSELECT id FROM roles\n")},
+		"queries/README.md": &fstest.MapFile{Data: []byte("not a query")},
+	}
+	r, err := LoadRegistry(fsys, "queries/*.sql", Placeholder(DollarNumbered))
+	if err != nil {
+		t.Fatalf("Fail: expect no error, got %v instead", err)
+	}
+	expectedNames := []string{"find_roles", "find_user_by_id", "find_users_by_ids"}
+	if strings.Join(r.Names(), ",") != strings.Join(expectedNames, ",") {
+		t.Errorf("Fail: expect %v queries, got %v instead", expectedNames, r.Names())
+	}
+	q := r.Get("find_user_by_id").With(Param("id", 1))
+	expectedSql := "SELECT id, name\nFROM users\nWHERE id = $1"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	q = r.Get("find_users_by_ids").With(Param("ids", 1, 2))
+	expectedSql = "-- Users with the given ids\nSELECT id, name FROM users WHERE id IN ($1, $2)"
+	if q.SQL() != expectedSql {
+		t.Errorf("Fail: expect [ %v ] sql string, got [ %v ] instead", expectedSql, q.SQL())
+	}
+	if q := r.Get("find_user_by_name").With(Param("name", "alice")); q.Error() == nil {
+		t.Errorf("Fail: expect unknown query error")
+	}
+}
+
+func TestLoadRegistryError(t *testing.T) {
+	cases := map[string]string{
+		"SELECT 1\n": "query without -- name: header",
+		"-- name: a\nSELECT 1\n-- name: a\nSELECT 2\n":  "duplicate query a",
+		"-- name: a\n\n-- name: b\nSELECT 2\n":          "query a is empty",
+		"-- name: a\nSELECT id FROM t WHERE a = 'abc\n": "unterminated",
+	}
+	for content, expected := range cases {
+		_, err := LoadRegistry(fstest.MapFS{"q.sql": &fstest.MapFile{Data: []byte(content)}}, "*.sql")
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Fail: expect error containing %v, got %v instead", expected, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package dbmapper
+
+import (
+	"fmt"
+)
+
+// ColumnPolicy controls how a column mismatch between the result and the
+// mapped columns is handled
+type ColumnPolicy int
+
+const (
+	// ColumnIgnore silently ignores the mismatch, unmapped result columns are
+	// discarded
+	ColumnIgnore ColumnPolicy = iota
+	// ColumnWarn logs the mismatch at LevelWarn
+	ColumnWarn
+	// ColumnError fails mapping with ColumnMismatchError
+	ColumnError
+)
+
+// ScanPlan resolves result columns to targets of MappedColumns once, so rows
+// can be scanned without allocating per row. It assumes the RowMapper returns
+// the same columns in the same order for every row
+type ScanPlan struct {
+	// index is the position in MappedColumns.Columns of every result column,
+	// -1 when unmapped
+	index []int
+	width int
+	// missing are mapped columns absent from the result
+	missing []string
+	// unmapped are result columns without a mapped column
+	unmapped []string
+	dest     []interface{}
+	discard  interface{}
+	// reuse is ReuseRow option recorded by Check
+	reuse bool
+}
+
+// NewScanPlan returns scan plan of result columns for rows mapped by mapped.
+// Unmapped result columns are scanned into discard
+func NewScanPlan(columns []string, mapped *MappedColumns, discard interface{}) *ScanPlan {
+	positions := make(map[string]int, len(mapped.Columns))
+	for i, column := range mapped.Columns {
+		positions[column.Name()] = i
+	}
+	index := make([]int, len(columns))
+	found := make(map[string]bool, len(columns))
+	unmapped := make([]string, 0)
+	for i, name := range columns {
+		found[name] = true
+		if pos, ok := positions[name]; ok {
+			index[i] = pos
+		} else {
+			index[i] = -1
+			unmapped = append(unmapped, name)
+		}
+	}
+	missing := make([]string, 0)
+	for _, column := range mapped.Columns {
+		if !found[column.Name()] {
+			missing = append(missing, column.Name())
+		}
+	}
+	return &ScanPlan{
+		index:    index,
+		width:    len(mapped.Columns),
+		missing:  missing,
+		unmapped: unmapped,
+		dest:     make([]interface{}, len(columns)),
+		discard:  discard,
+	}
+}
+
+// Check applies column policies of o to the columns mismatch, returning
+// ColumnMismatchError listing both missing and unmapped columns. It also
+// records whether o reuses the mapped row for every row
+func (p *ScanPlan) Check(o *Options) error {
+	p.reuse = o.ReuseRow
+	missing := len(p.missing) > 0
+	unmapped := len(p.unmapped) > 0
+	if (missing && o.MissingColumns == ColumnError) || (unmapped && o.UnmappedColumns == ColumnError) {
+		return &ColumnMismatchError{p.missing, p.unmapped}
+	}
+	if (missing && o.MissingColumns == ColumnWarn) || (unmapped && o.UnmappedColumns == ColumnWarn) {
+		o.Log(LevelWarn, "column mismatch", Field{Key: "missing", Value: p.missing}, Field{Key: "unmapped", Value: p.unmapped})
+	}
+	return nil
+}
+
+// Dest returns scan destinations of the row mapped by mapped. The returned
+// slice is reused by subsequent calls
+func (p *ScanPlan) Dest(mapped *MappedColumns) ([]interface{}, error) {
+	if len(mapped.Columns) != p.width {
+		return nil, fmt.Errorf("row mapper returned %d columns, expected %d", len(mapped.Columns), p.width)
+	}
+	mapped.reused = p.reuse
+	for _, column := range mapped.Columns {
+		if err := column.Error(); err != nil {
+			return nil, err
+		}
+	}
+	for i, pos := range p.index {
+		if pos < 0 {
+			p.dest[i] = p.discard
+		} else {
+			p.dest[i] = *mapped.Columns[pos].Target()
+		}
+	}
+	return p.dest, nil
+}
@@ -0,0 +1,61 @@
+package dbmapper
+
+import (
+	"testing"
+)
+
+func TestScanPlan(t *testing.T) {
+	var id, name string
+	discard := new(int)
+	mapped := Columns(Column("id").As(&id), Column("name").As(&name))
+	plan := NewScanPlan([]string{"name", "extra", "id"}, mapped, discard)
+	dest, err := plan.Dest(mapped)
+	if err != nil {
+		t.Fatalf("Fail: expect no error got %v instead", err)
+	}
+	if len(dest) != 3 || dest[0] != &name || dest[1] != discard || dest[2] != &id {
+		t.Errorf("Fail: expect destinations [&name discard &id] got %v instead", dest)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { plan.Dest(mapped) }); allocs != 0 {
+		t.Errorf("Fail: expect no allocation per row got %v instead", allocs)
+	}
+	if _, err = plan.Dest(Columns(Column("id").As(&id))); err == nil {
+		t.Errorf("Fail: expect error for different column count got nil instead")
+	}
+	if _, err = plan.Dest(Columns(Column("id").As(&id), Column("name").As(nil))); err == nil {
+		t.Errorf("Fail: expect column mapping error got nil instead")
+	}
+}
+
+func TestScanPlanCheck(t *testing.T) {
+	var id, phone string
+	mapped := Columns(Column("id").As(&id), Column("phone_numbr").As(&phone))
+	plan := NewScanPlan([]string{"id", "phone_number"}, mapped, nil)
+	if err := plan.Check(NewOptions()); err != nil {
+		t.Errorf("Fail: expect mismatch to be ignored by default got %v instead", err)
+	}
+	warnings := 0
+	logger := LoggerFunc(func(level Level, msg string, fields ...Field) {
+		if level == LevelWarn {
+			warnings++
+		}
+	})
+	if err := plan.Check(NewOptions(CheckColumns(ColumnError, ColumnWarn), UseLogger(logger))); err == nil {
+		t.Errorf("Fail: expect error for missing column got nil instead")
+	}
+	if err := plan.Check(NewOptions(CheckColumns(ColumnIgnore, ColumnWarn), UseLogger(logger))); err != nil || warnings != 1 {
+		t.Errorf("Fail: expect a warning for unmapped column got %v and %d warnings instead", err, warnings)
+	}
+	err := plan.Check(NewOptions(StrictColumns()))
+	mismatch, ok := err.(*ColumnMismatchError)
+	if !ok {
+		t.Fatalf("Fail: expect *ColumnMismatchError got %v instead", err)
+	}
+	if len(mismatch.Missing) != 1 || mismatch.Missing[0] != "phone_numbr" || len(mismatch.Unmapped) != 1 || mismatch.Unmapped[0] != "phone_number" {
+		t.Errorf("Fail: expect missing [phone_numbr] and unmapped [phone_number] got %v instead", mismatch)
+	}
+	exact := NewScanPlan([]string{"phone_numbr", "id"}, mapped, nil)
+	if err := exact.Check(NewOptions(StrictColumns())); err != nil {
+		t.Errorf("Fail: expect matching columns to pass got %v instead", err)
+	}
+}
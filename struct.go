@@ -0,0 +1,125 @@
+package dbmapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// fieldPlans caches mapped fields per struct type
+var fieldPlans sync.Map
+
+// cachedFields returns structFields of t, computed once per type
+func cachedFields(t reflect.Type) []structField {
+	if fields, ok := fieldPlans.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := fieldPlans.LoadOrStore(t, structFields(t))
+	return fields.([]structField)
+}
+
+// Struct returns a row mapper appending every row to dst, a pointer to slice
+// of structs or struct pointers. Columns are mapped to fields by their `db`
+// tag or snake cased field name, see ParamsFromStruct
+func Struct(dst interface{}) RowMapper {
+	slice := reflect.ValueOf(dst)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return invalidMapper(fmt.Errorf("cannot map rows into %T, expected pointer to slice", dst))
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return invalidMapper(fmt.Errorf("cannot map rows into %T, expected slice of structs", dst))
+	}
+	fields := cachedFields(elemType)
+	embedded := embeddedPtrs(elemType)
+	return func() *MappedColumns {
+		row := reflect.New(elemType)
+		columns := make([]ColumnMap, len(fields))
+		for i, f := range fields {
+			columns[i] = Column(f.name).As(allocField(row.Elem(), f.index).Addr().Interface())
+		}
+		mapped := Columns(columns...)
+		return mapped.Then(func() error {
+			value := row
+			if mapped.reused {
+				// Copy the row, its target is scanned again for the next row
+				value = reflect.New(elemType)
+				value.Elem().Set(row.Elem())
+				for _, index := range embedded {
+					if v, ok := fieldByIndex(value.Elem(), index); ok && !v.IsNil() {
+						fresh := reflect.New(v.Type().Elem())
+						fresh.Elem().Set(v.Elem())
+						v.Set(fresh)
+					}
+				}
+			}
+			if isPtr {
+				slice.Set(reflect.Append(slice, value))
+			} else {
+				slice.Set(reflect.Append(slice, value.Elem()))
+			}
+			return nil
+		})
+	}
+}
+
+// embeddedPtrs returns index of embedded struct pointers of t, outer ones
+// first
+func embeddedPtrs(t reflect.Type) [][]int {
+	indexes := make([][]int, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, tagged := f.Tag.Lookup("db"); !f.Anonymous || tagged {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			if f.PkgPath != "" {
+				// Skipped by structFields
+				continue
+			}
+			ft = ft.Elem()
+			if ft.Kind() == reflect.Struct {
+				indexes = append(indexes, []int{i})
+			}
+		}
+		if ft.Kind() == reflect.Struct {
+			for _, index := range embeddedPtrs(ft) {
+				indexes = append(indexes, append([]int{i}, index...))
+			}
+		}
+	}
+	return indexes
+}
+
+// StructOf returns a row mapper appending every row to dst, see Struct
+func StructOf[T any](dst *[]T) RowMapper {
+	return Struct(dst)
+}
+
+// allocField returns field of v at index, allocating nil embedded struct
+// pointers on the way
+func allocField(v reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// invalidMapper returns a row mapper failing with err
+func invalidMapper(err error) RowMapper {
+	return func() *MappedColumns {
+		return Columns(&column{err: err})
+	}
+}